@@ -0,0 +1,165 @@
+package emailscraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// defaultActionTimeout is the default time a browser action and the requests it triggers may take.
+	defaultActionTimeout = 5 * time.Second
+	// defaultActionRounds is the default number of times the configured actions are run on a page.
+	defaultActionRounds = 1
+)
+
+// errUnknownAction is returned for browser actions of an unsupported kind.
+var errUnknownAction = errors.New("unknown browser action")
+
+// ActionKind selects what a BrowserAction does with the elements it matches.
+type ActionKind int
+
+const (
+	// ActionClick clicks every matching element, e.g. "Show email" buttons.
+	ActionClick ActionKind = iota
+	// ActionHover moves the pointer over every matching element to trigger hover handlers.
+	ActionHover
+	// ActionExpandDetails opens every matching <details> element; an empty selector matches all of them.
+	ActionExpandDetails
+)
+
+// BrowserAction is an interaction run on rendered pages to reveal hidden addresses before the DOM is captured.
+type BrowserAction struct {
+	Kind ActionKind
+	// Selector is the CSS selector of the elements to act on.
+	Selector string
+	// Timeout bounds the action and the requests it triggers; zero uses a short default.
+	Timeout time.Duration
+}
+
+// actionScripts are the JavaScript bodies of each action kind, run with the matched elements as "elements".
+//
+//nolint:gochecknoglobals // read-only lookup table
+var actionScripts = map[ActionKind]string{
+	ActionClick: `elements.forEach((element) => element.click());`,
+	ActionHover: `elements.forEach((element) => {
+		for (const type of ["pointerover", "pointerenter", "mouseover", "mouseenter", "mousemove"]) {
+			element.dispatchEvent(new MouseEvent(type, {bubbles: type.endsWith("over") || type === "mousemove"}));
+		}
+	});`,
+	ActionExpandDetails: `elements.forEach((element) => { if ("open" in element) element.open = true; });`,
+}
+
+// script returns the JavaScript that performs the action and evaluates to the number of matched elements.
+func (a BrowserAction) script() (string, error) {
+	body, ok := actionScripts[a.Kind]
+	if !ok {
+		return "", fmt.Errorf("%w: %d", errUnknownAction, a.Kind)
+	}
+
+	selector := a.Selector
+	if selector == "" && a.Kind == ActionExpandDetails {
+		selector = "details"
+	}
+
+	quoted, err := json.Marshal(selector)
+	if err != nil {
+		return "", fmt.Errorf("encoding selector: %w", err)
+	}
+
+	return fmt.Sprintf(`(() => {
+	let elements;
+	try {
+		elements = Array.from(document.querySelectorAll(%s));
+	} catch (error) {
+		return 0;
+	}
+	%s
+	return elements.length;
+})()`, quoted, body), nil
+}
+
+// run performs the action and then waits, within the action timeout, for the requests it triggered.
+// It reports how many elements were acted on; failures of a single action do not fail the render.
+func (a BrowserAction) run(ctx context.Context, tracker *networkTracker) (int, error) {
+	script, err := a.script()
+	if err != nil {
+		return 0, err
+	}
+
+	timeout := a.Timeout
+	if timeout <= 0 {
+		timeout = defaultActionTimeout
+	}
+
+	actionCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var matched int
+
+	err = chromedp.Evaluate(script, &matched).Do(actionCtx)
+	if ctx.Err() != nil {
+		return 0, fmt.Errorf("running browser action: %w", ctx.Err())
+	}
+
+	if err != nil || matched == 0 {
+		return 0, nil
+	}
+
+	// Revealed content is often fetched on demand; the wait ends with the action timeout at the latest
+	_ = tracker.waitIdle(defaultNetworkIdleTime)(actionCtx)
+
+	return matched, nil
+}
+
+// revealActions returns an action that runs the configured browser actions for the given number of
+// rounds and appends a DOM snapshot (and visible text, when extractText is set) after every round.
+// Rounds stop early once no action matches anything.
+func revealActions(
+	actions []BrowserAction, rounds int, extractText bool, tracker *networkTracker, snapshots *[]string,
+) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		for range rounds {
+			matched := 0
+
+			for _, action := range actions {
+				count, err := action.run(ctx, tracker)
+				if err != nil {
+					return err
+				}
+
+				matched += count
+			}
+
+			if matched == 0 {
+				return nil
+			}
+
+			var html string
+
+			err := chromedp.InnerHTML("html", &html, chromedp.ByQuery).Do(ctx)
+			if err != nil {
+				return fmt.Errorf("capturing revealed DOM: %w", err)
+			}
+
+			*snapshots = append(*snapshots, html)
+
+			if extractText {
+				var text string
+
+				err = visibleText(&text).Do(ctx)
+				if err != nil {
+					return fmt.Errorf("capturing revealed text: %w", err)
+				}
+
+				*snapshots = append(*snapshots, text)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBrowserActionScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		action       BrowserAction
+		wantContains []string
+		wantErr      error
+	}{
+		{
+			name:         "click quotes selector",
+			action:       BrowserAction{Kind: ActionClick, Selector: `a[data-x="1"]`, Timeout: 0},
+			wantContains: []string{`querySelectorAll("a[data-x=\"1\"]")`, "element.click()"},
+			wantErr:      nil,
+		},
+		{
+			name:         "hover dispatches mouse events",
+			action:       BrowserAction{Kind: ActionHover, Selector: ".email", Timeout: 0},
+			wantContains: []string{`querySelectorAll(".email")`, "mouseover"},
+			wantErr:      nil,
+		},
+		{
+			name:         "expand defaults to details",
+			action:       BrowserAction{Kind: ActionExpandDetails, Selector: "", Timeout: 0},
+			wantContains: []string{`querySelectorAll("details")`, "element.open = true"},
+			wantErr:      nil,
+		},
+		{
+			name:         "unknown kind",
+			action:       BrowserAction{Kind: ActionKind(99), Selector: "a", Timeout: 0},
+			wantContains: nil,
+			wantErr:      errUnknownAction,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := testCase.action.script()
+			if !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("script() error = %v, want %v", err, testCase.wantErr)
+			}
+
+			for _, want := range testCase.wantContains {
+				if !strings.Contains(script, want) {
+					t.Errorf("script() = %q, want it to contain %q", script, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package emailscraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchResult is the outcome of scraping a single site in a batch.
+type BatchResult struct {
+	// URL is the site as it was passed in.
+	URL    string
+	Result Result
+	Err    error
+}
+
+// batchJob is a site queued for a batch scrape.
+type batchJob struct {
+	index int
+	url   string
+}
+
+// ScrapeMany scrapes every url with at most Config.BatchConcurrency sites in flight.
+// Results are returned in input order; a failing site only affects its own result.
+func (s *Scraper) ScrapeMany(ctx context.Context, urls []string) []BatchResult {
+	results := make([]BatchResult, len(urls))
+	started := make([]bool, len(urls))
+
+	jobs := make(chan batchJob)
+
+	go func() {
+		defer close(jobs)
+
+		for index, url := range urls {
+			select {
+			case jobs <- batchJob{index: index, url: url}:
+				started[index] = true
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.runBatch(ctx, jobs, func(index int, result BatchResult) {
+		results[index] = result
+	})
+
+	for index, url := range urls {
+		if started[index] {
+			continue
+		}
+
+		var skipped Result
+
+		skipped.URL = getWebsite(url, true)
+
+		results[index] = BatchResult{URL: url, Result: skipped, Err: fmt.Errorf("scraping canceled: %w", ctx.Err())}
+	}
+
+	return results
+}
+
+// ScrapeBatch scrapes urls as they arrive with at most Config.BatchConcurrency sites in flight.
+// Results are sent in completion order; the returned channel is closed once urls is closed
+// (or ctx is done) and every started site has finished. The caller must drain it.
+func (s *Scraper) ScrapeBatch(ctx context.Context, urls <-chan string) <-chan BatchResult {
+	results := make(chan BatchResult)
+	jobs := make(chan batchJob)
+
+	go func() {
+		defer close(jobs)
+
+		for index := 0; ; index++ {
+			select {
+			case url, ok := <-urls:
+				if !ok {
+					return
+				}
+
+				select {
+				case jobs <- batchJob{index: index, url: url}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(results)
+
+		s.runBatch(ctx, jobs, func(_ int, result BatchResult) {
+			results <- result
+		})
+	}()
+
+	return results
+}
+
+// runBatch scrapes every job with bounded concurrency and reports each outcome to emit.
+func (s *Scraper) runBatch(ctx context.Context, jobs <-chan batchJob, emit func(index int, result BatchResult)) {
+	concurrency := s.cfg.BatchConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	var waitGroup sync.WaitGroup
+
+	for range concurrency {
+		waitGroup.Go(func() {
+			for job := range jobs {
+				result, err := s.ScrapeDetailed(ctx, job.url)
+
+				emit(job.index, BatchResult{URL: job.url, Result: result, Err: err})
+			}
+		})
+	}
+
+	waitGroup.Wait()
+}
@@ -0,0 +1,132 @@
+package emailscraper
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// defaultBlockedResourceTypes are the resource types rendered pages do not load by default.
+func defaultBlockedResourceTypes() []string {
+	return []string{
+		string(network.ResourceTypeImage),
+		string(network.ResourceTypeMedia),
+		string(network.ResourceTypeFont),
+	}
+}
+
+// defaultBlockedURLPatterns match well-known analytics, advertising and tracking hosts.
+func defaultBlockedURLPatterns() []*regexp.Regexp {
+	hosts := []string{
+		`google-analytics\.com`, `googletagmanager\.com`, `googlesyndication\.com`, `doubleclick\.net`,
+		`googleadservices\.com`, `connect\.facebook\.net`, `static\.hotjar\.com`, `cdn\.segment\.com`,
+		`cdn\.mxpnl\.com`, `js\.hs-analytics\.net`, `snap\.licdn\.com`, `bat\.bing\.com`,
+		`static\.ads-twitter\.com`, `clarity\.ms`, `cdn\.heapanalytics\.com`, `plausible\.io`,
+	}
+
+	return []*regexp.Regexp{
+		regexp.MustCompile(`^https?://([^/]+\.)?(` + strings.Join(hosts, "|") + `)(:\d+)?/`),
+	}
+}
+
+// requestBlocker stops rendered pages from loading blocked resources and counts what it stopped.
+type requestBlocker struct {
+	types    []string
+	patterns []*regexp.Regexp
+
+	m       sync.Mutex
+	blocked map[string]int
+}
+
+func newRequestBlocker(cfg ChromeConfig) *requestBlocker {
+	return &requestBlocker{
+		types:    cfg.BlockResourceTypes,
+		patterns: cfg.BlockURLPatterns,
+		m:        sync.Mutex{},
+		blocked:  make(map[string]int),
+	}
+}
+
+// enabled reports whether anything is blocked at all, so interception can be skipped otherwise.
+func (b *requestBlocker) enabled() bool {
+	return len(b.types) > 0 || len(b.patterns) > 0
+}
+
+// blocks reports whether a request for url of the given resource type must not be loaded.
+// The main document is never blocked.
+func (b *requestBlocker) blocks(resourceType network.ResourceType, url string) bool {
+	if resourceType == network.ResourceTypeDocument {
+		return false
+	}
+
+	for _, blockedType := range b.types {
+		if strings.EqualFold(blockedType, string(resourceType)) {
+			return true
+		}
+	}
+
+	for _, pattern := range b.patterns {
+		if pattern.MatchString(url) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enable returns the action that turns on request interception in the tab of ctx.
+func (b *requestBlocker) enable(ctx context.Context) chromedp.Action {
+	chromedp.ListenTarget(ctx, func(event any) {
+		paused, ok := event.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+
+		// Listeners must not block, so answer the paused request asynchronously
+		go b.resolve(ctx, paused)
+	})
+
+	return fetch.Enable()
+}
+
+// resolve fails or continues a paused request.
+func (b *requestBlocker) resolve(ctx context.Context, paused *fetch.EventRequestPaused) {
+	executor := chromedp.FromContext(ctx)
+	if executor == nil || executor.Target == nil {
+		return
+	}
+
+	url := ""
+	if paused.Request != nil {
+		url = paused.Request.URL
+	}
+
+	ctx = cdp.WithExecutor(ctx, executor.Target)
+
+	if !b.blocks(paused.ResourceType, url) {
+		_ = fetch.ContinueRequest(paused.RequestID).Do(ctx)
+
+		return
+	}
+
+	b.m.Lock()
+	b.blocked[string(paused.ResourceType)]++
+	b.m.Unlock()
+
+	_ = fetch.FailRequest(paused.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+}
+
+// counts returns the number of blocked requests per resource type.
+func (b *requestBlocker) counts() map[string]int {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	return maps.Clone(b.blocked)
+}
@@ -0,0 +1,71 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestRequestBlockerBlocks(t *testing.T) {
+	t.Parallel()
+
+	blocker := newRequestBlocker(DefaultChromeConfig())
+
+	tests := []struct {
+		name         string
+		resourceType network.ResourceType
+		url          string
+		expected     bool
+	}{
+		{"document", network.ResourceTypeDocument, "https://example.com/", false},
+		{"image", network.ResourceTypeImage, "https://example.com/logo.png", true},
+		{"font", network.ResourceTypeFont, "https://fonts.example.com/a.woff2", true},
+		{"media", network.ResourceTypeMedia, "https://example.com/intro.mp4", true},
+		{"script", network.ResourceTypeScript, "https://example.com/app.js", false},
+		{"xhr", network.ResourceTypeXHR, "https://example.com/api/team", false},
+		{"tracker", network.ResourceTypeScript, "https://www.googletagmanager.com/gtm.js?id=1", true},
+		{"tracker subdomain", network.ResourceTypeScript, "https://ssl.google-analytics.com/ga.js", true},
+		{"tracker lookalike", network.ResourceTypeScript, "https://example.com/google-analytics.com/x.js", false},
+		{"tracker document", network.ResourceTypeDocument, "https://connect.facebook.net/", false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := blocker.blocks(testCase.resourceType, testCase.url); got != testCase.expected {
+				t.Errorf("blocks(%q, %q) = %v, want %v", testCase.resourceType, testCase.url, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestRequestBlockerEnabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultChromeConfig()
+	if !newRequestBlocker(cfg).enabled() {
+		t.Errorf("default blocker is not enabled")
+	}
+
+	cfg.BlockResourceTypes = nil
+	cfg.BlockURLPatterns = nil
+
+	if newRequestBlocker(cfg).enabled() {
+		t.Errorf("blocker without rules is enabled")
+	}
+}
+
+func TestCrawlStatsBlocked(t *testing.T) {
+	t.Parallel()
+
+	stats := newCrawlStats()
+	stats.addBlocked(map[string]int{"Image": 2, "Font": 1})
+	stats.addBlocked(map[string]int{"Image": 3})
+
+	blocked := stats.snapshot(0).BlockedRequests
+	if blocked["Image"] != 5 || blocked["Font"] != 1 || len(blocked) != 2 {
+		t.Errorf("unexpected blocked counts %v", blocked)
+	}
+}
@@ -0,0 +1,112 @@
+package emailscraper
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// maxCapturedBodySize is the largest API response body that is scanned for emails.
+const maxCapturedBodySize = 5 << 20
+
+// capturedResponse is the body of an XHR or fetch response made by a rendered page.
+type capturedResponse struct {
+	url  string
+	body []byte
+}
+
+// responseCapture collects the XHR and fetch responses a page makes while it is rendered.
+type responseCapture struct {
+	m        sync.Mutex
+	pending  map[network.RequestID]string
+	finished []network.RequestID
+	urls     map[network.RequestID]string
+}
+
+func newResponseCapture() *responseCapture {
+	return &responseCapture{
+		m:        sync.Mutex{},
+		pending:  make(map[network.RequestID]string),
+		finished: nil,
+		urls:     make(map[network.RequestID]string),
+	}
+}
+
+// listen records network events; it is registered with chromedp.ListenTarget.
+// Bodies are not fetched here, as listeners must not issue commands to the target.
+func (c *responseCapture) listen(event any) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	switch event := event.(type) {
+	case *network.EventResponseReceived:
+		if event.Response != nil && isCapturableResponse(event.Type, event.Response.MimeType) {
+			c.pending[event.RequestID] = event.Response.URL
+		}
+	case *network.EventLoadingFinished:
+		url, ok := c.pending[event.RequestID]
+		if !ok {
+			return
+		}
+
+		delete(c.pending, event.RequestID)
+
+		if event.EncodedDataLength > maxCapturedBodySize {
+			return
+		}
+
+		c.urls[event.RequestID] = url
+		c.finished = append(c.finished, event.RequestID)
+	}
+}
+
+// collect returns an action that fetches the bodies of all finished responses into responses.
+// Bodies that are no longer available are skipped.
+func (c *responseCapture) collect(responses *[]capturedResponse) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		c.m.Lock()
+		finished := c.finished
+		c.finished = nil
+		c.m.Unlock()
+
+		for _, requestID := range finished {
+			body, err := network.GetResponseBody(requestID).Do(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return fmt.Errorf("fetching response bodies: %w", ctx.Err())
+				}
+
+				continue
+			}
+
+			c.m.Lock()
+			url := c.urls[requestID]
+			c.m.Unlock()
+
+			*responses = append(*responses, capturedResponse{url: url, body: body})
+		}
+
+		return nil
+	}
+}
+
+// isCapturableResponse reports whether a response of the given type is an API call with a text body.
+func isCapturableResponse(resourceType network.ResourceType, contentType string) bool {
+	if resourceType != network.ResourceTypeXHR && resourceType != network.ResourceTypeFetch {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json")
+}
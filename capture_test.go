@@ -0,0 +1,79 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestIsCapturableResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		resourceType network.ResourceType
+		contentType  string
+		expected     bool
+	}{
+		{"xhr json", network.ResourceTypeXHR, "application/json; charset=utf-8", true},
+		{"fetch json-ld", network.ResourceTypeFetch, "application/ld+json", true},
+		{"fetch html", network.ResourceTypeFetch, "text/html", true},
+		{"xhr image", network.ResourceTypeXHR, "image/png", false},
+		{"script", network.ResourceTypeScript, "text/javascript", false},
+		{"document", network.ResourceTypeDocument, "text/html", false},
+		{"invalid type", network.ResourceTypeXHR, ";", false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := isCapturableResponse(testCase.resourceType, testCase.contentType); got != testCase.expected {
+				t.Errorf("isCapturableResponse(%q, %q) = %v, want %v",
+					testCase.resourceType, testCase.contentType, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestResponseCaptureListen(t *testing.T) {
+	t.Parallel()
+
+	capture := newResponseCapture()
+
+	//nolint:exhaustruct // only the inspected fields are set
+	events := []any{
+		&network.EventResponseReceived{
+			RequestID: "api", Type: network.ResourceTypeFetch,
+			Response: &network.Response{URL: "https://example.com/api/team", MimeType: "application/json"},
+		},
+		&network.EventResponseReceived{
+			RequestID: "img", Type: network.ResourceTypeImage,
+			Response: &network.Response{URL: "https://example.com/logo.png", MimeType: "image/png"},
+		},
+		&network.EventResponseReceived{
+			RequestID: "big", Type: network.ResourceTypeXHR,
+			Response: &network.Response{URL: "https://example.com/api/dump", MimeType: "application/json"},
+		},
+		&network.EventLoadingFinished{RequestID: "img", EncodedDataLength: 10},
+		&network.EventLoadingFinished{RequestID: "big", EncodedDataLength: maxCapturedBodySize + 1},
+		&network.EventLoadingFinished{RequestID: "api", EncodedDataLength: 100},
+	}
+
+	for _, event := range events {
+		capture.listen(event)
+	}
+
+	if len(capture.finished) != 1 || capture.finished[0] != "api" {
+		t.Fatalf("expected only the API response to be captured, got %v", capture.finished)
+	}
+
+	if url := capture.urls["api"]; url != "https://example.com/api/team" {
+		t.Errorf("captured URL = %q", url)
+	}
+
+	if len(capture.pending) != 0 {
+		t.Errorf("expected no pending responses, got %v", capture.pending)
+	}
+}
@@ -3,8 +3,11 @@ package emailscraper
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
+	"regexp"
+	"slices"
 	"time"
 
 	"github.com/chromedp/chromedp"
@@ -18,6 +21,107 @@ const (
 	defaultChromeWindowHeight = 1080
 )
 
+// ChromeConfig configures the headless browsers used for JavaScript rendering.
+type ChromeConfig struct {
+	// Mode selects which HTML pages are rendered.
+	Mode RenderMode
+	// Strategy controls what every rendered page is waited for before its DOM is captured.
+	Strategy RenderStrategy
+	// Rules override Strategy for matching URLs; the first matching rule wins.
+	Rules []RenderRule
+	// DismissConsent clicks away cookie consent prompts of common consent management platforms and
+	// generic accept (or reject) buttons in several languages before the DOM is captured. It is off by
+	// default, since clicking and removing elements changes the pages that are rendered.
+	DismissConsent bool
+	// ConsentSelectors are CSS selectors of additional consent or overlay buttons to click; they are
+	// tried before the built-in ones.
+	ConsentSelectors []string
+	// Actions are run on every rendered page to reveal hidden addresses, e.g. by clicking
+	// "Show email" buttons. The DOM is captured again after each round of actions.
+	Actions []BrowserAction
+	// ActionRounds is the number of times Actions are run on a page; zero uses one round.
+	ActionRounds int
+	// ExtractText also scans the visible text of rendered pages, including CSS generated content,
+	// open shadow roots and same-origin iframes that the HTML alone does not show.
+	ExtractText bool
+	// CaptureResponses scans the text and JSON bodies of XHR and fetch responses made by rendered pages.
+	CaptureResponses bool
+	// BlockResourceTypes lists DevTools resource types ("Image", "Media", "Font", "Stylesheet", ...)
+	// rendered pages are not allowed to load.
+	BlockResourceTypes []string
+	// BlockURLPatterns stops rendered pages from loading any URL they match.
+	BlockURLPatterns []*regexp.Regexp
+
+	// RemoteURL attaches to already running browsers over the DevTools protocol instead of launching
+	// local ones. Both websocket (ws://host:9222/devtools/browser/...) and HTTP (http://host:9222)
+	// endpoints are accepted. The launch options below are ignored when it is set.
+	RemoteURL string
+
+	// Headful runs launched browsers with a window; they are headless by default.
+	Headful bool
+	// UserDataDir is the profile directory of launched browsers; empty uses a temporary profile.
+	// A profile can only be used by one browser, so leave it empty when MaxBrowsers is above one.
+	UserDataDir string
+	// Proxy is the proxy server of launched browsers, e.g. "http://127.0.0.1:3128" or "socks5://127.0.0.1:1080".
+	Proxy string
+	// Flags are command-line flags of launched browsers, without the leading dashes. Values must be
+	// strings or booleans; a false value removes a flag that is set by default. Nil uses
+	// DefaultChromeFlags. Browsers use the user agent of plain requests unless a "user-agent" flag is given.
+	Flags map[string]any
+
+	// MaxBrowsers is the number of browser processes the pool keeps alive.
+	MaxBrowsers int
+	// MaxTabs limits how many pages are rendered at once across all browsers.
+	MaxTabs int
+	// IdleTimeout closes browsers that have not rendered a page for this long; zero keeps them until Close.
+	IdleTimeout time.Duration
+	// RecycleAfter restarts a browser after it has rendered this many pages; zero never restarts.
+	RecycleAfter int
+}
+
+// DefaultChromeConfig defines the browser pool defaults.
+func DefaultChromeConfig() ChromeConfig {
+	return ChromeConfig{
+		Mode: RenderAlways,
+		Strategy: RenderStrategy{
+			WaitSelector:    "",
+			ScrollTimes:     0,
+			ScrollDelay:     0,
+			WaitNetworkIdle: false,
+			NetworkIdleTime: 0,
+			SettleDelay:     0,
+		},
+		Rules:              nil,
+		DismissConsent:     false,
+		ConsentSelectors:   nil,
+		Actions:            nil,
+		ActionRounds:       defaultActionRounds,
+		ExtractText:        true,
+		CaptureResponses:   true,
+		BlockResourceTypes: defaultBlockedResourceTypes(),
+		BlockURLPatterns:   defaultBlockedURLPatterns(),
+		RemoteURL:          "",
+		Headful:            false,
+		UserDataDir:        "",
+		Proxy:              "",
+		Flags:              DefaultChromeFlags(),
+		MaxBrowsers:        defaultMaxBrowsers,
+		MaxTabs:            defaultMaxTabs,
+		IdleTimeout:        defaultBrowserIdleTimeout,
+		RecycleAfter:       defaultBrowserRecycleAfter,
+	}
+}
+
+// DefaultChromeFlags returns the command-line flags launched browsers get unless ChromeConfig.Flags is
+// set: no GPU, no sandbox (for containers running as root) and a desktop-sized window.
+func DefaultChromeFlags() map[string]any {
+	return map[string]any{
+		"disable-gpu": true,
+		"no-sandbox":  true,
+		"window-size": fmt.Sprintf("%d,%d", defaultChromeWindowWidth, defaultChromeWindowHeight),
+	}
+}
+
 // findChromePath attempts to find Chrome/Chromium executable.
 func findChromePath() string {
 	// Check environment variable first
@@ -36,50 +140,154 @@ func findChromePath() string {
 	return ""
 }
 
-func initiateScrapingFromChrome(response *colly.Response, timeout int) error {
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.WindowSize(defaultChromeWindowWidth, defaultChromeWindowHeight),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-	)
+// chromeAllocatorOptions returns the options used to launch local browsers.
+func chromeAllocatorOptions(cfg ChromeConfig) []chromedp.ExecAllocatorOption {
+	opts := slices.Clone(chromedp.DefaultExecAllocatorOptions[:])
+
+	for name, value := range chromeFlags(cfg) {
+		opts = append(opts, chromedp.Flag(name, value))
+	}
 
 	// Add custom Chrome path if found
 	if chromePath := findChromePath(); chromePath != "" {
 		opts = append(opts, chromedp.ExecPath(chromePath))
 	}
 
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	return opts
+}
+
+// chromeFlags returns the command-line flags launched browsers get on top of the chromedp defaults.
+func chromeFlags(cfg ChromeConfig) map[string]any {
+	flags := map[string]any{
+		"headless": !cfg.Headful,
+		// Rendered pages see the same user agent as plain requests, not the one of headless Chrome
+		"user-agent": defaultUserAgent,
+	}
+
+	if cfg.Flags == nil {
+		maps.Copy(flags, DefaultChromeFlags())
+	}
+
+	maps.Copy(flags, cfg.Flags)
+
+	if cfg.UserDataDir != "" {
+		flags["user-data-dir"] = cfg.UserDataDir
+	}
+
+	if cfg.Proxy != "" {
+		flags["proxy-server"] = cfg.Proxy
+	}
+
+	return flags
+}
+
+// renderOutput is what a render found besides the DOM.
+type renderOutput struct {
+	// text is the visible text of the page, when text extraction is enabled.
+	text string
+	// revealed are the DOM snapshots (and visible texts) taken after each round of browser actions.
+	revealed []string
+	// captured are the XHR and fetch responses made by the page.
+	captured []capturedResponse
+	// blocked counts the requests that were not loaded, per resource type.
+	blocked map[string]int
+}
+
+// parseRendered parses what rendering the page at page found. Its visible text and the snapshots taken
+// after browser actions are attributed to the page, emails delivered by its API calls to the API URL.
+func (s *emails) parseRendered(output renderOutput, page origin) {
+	if output.text != "" {
+		s.parseEmails([]byte(output.text), page)
+	}
+
+	for _, snapshot := range output.revealed {
+		s.parseEmails([]byte(snapshot), page)
+	}
 
-	defer func() {
-		// Graceful shutdown waits for browser to close
-		_ = chromedp.Cancel(allocCtx)
+	for _, apiResponse := range output.captured {
+		s.parseEmails(apiResponse.body, origin{url: apiResponse.url, depth: page.depth})
+	}
+}
 
-		allocCancel()
-	}()
+// initiateScrapingFromChrome renders the response URL in a pooled browser tab and replaces the body
+// with the rendered DOM. It reports the visible text, the snapshots taken after browser actions, the XHR
+// and fetch responses the page made and the requests that were blocked.
+// The tab is closed when ctx is done, so a canceled scrape never leaves it open.
+func initiateScrapingFromChrome(
+	ctx context.Context, pool *browserPool, response *colly.Response, timeout int,
+) (renderOutput, error) {
+	var output renderOutput
 
-	ctx, cancel := chromedp.NewContext(allocCtx)
-	defer cancel()
+	tabCtx, release, err := pool.acquire(ctx)
+	if err != nil {
+		return output, fmt.Errorf("acquiring browser tab: %w", err)
+	}
+	defer release()
 
 	if timeout > 0 {
 		var timeoutCancel context.CancelFunc
 
-		ctx, timeoutCancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		tabCtx, timeoutCancel = context.WithTimeout(tabCtx, time.Duration(timeout)*time.Second)
 
 		defer timeoutCancel()
 	}
 
+	url := response.Request.URL.String()
+
+	tracker := newNetworkTracker()
+	chromedp.ListenTarget(tabCtx, tracker.listen)
+
+	capture := newResponseCapture()
+	if pool.cfg.CaptureResponses {
+		chromedp.ListenTarget(tabCtx, capture.listen)
+	}
+
 	var res string
 
-	err := chromedp.Run(ctx,
-		chromedp.Navigate(response.Request.URL.String()),
+	actions := make([]chromedp.Action, 0)
+
+	blocker := newRequestBlocker(pool.cfg)
+	if blocker.enabled() {
+		actions = append(actions, blocker.enable(tabCtx))
+	}
+
+	actions = append(actions,
+		chromedp.Navigate(url),
 		chromedp.WaitReady("body", chromedp.ByQuery),
-		chromedp.InnerHTML("html", &res),
 	)
+	actions = append(actions, pool.cfg.strategyFor(url).waitActions(tracker)...)
+
+	if pool.cfg.DismissConsent {
+		actions = append(actions, dismissConsent(pool.cfg.ConsentSelectors, tracker))
+	}
+
+	actions = append(actions, chromedp.InnerHTML("html", &res))
+
+	if pool.cfg.ExtractText {
+		actions = append(actions, visibleText(&output.text))
+	}
+
+	if len(pool.cfg.Actions) > 0 {
+		rounds := pool.cfg.ActionRounds
+		if rounds <= 0 {
+			rounds = defaultActionRounds
+		}
+
+		actions = append(actions,
+			revealActions(pool.cfg.Actions, rounds, pool.cfg.ExtractText, tracker, &output.revealed))
+	}
+
+	actions = append(actions, capture.collect(&output.captured))
+
+	err = chromedp.Run(tabCtx, actions...)
+
+	output.blocked = blocker.counts()
+
 	if err != nil {
-		return fmt.Errorf("chromedp execution: %w", err)
+		return output, fmt.Errorf("chromedp execution: %w", err)
 	}
 
 	response.Body = []byte(res)
 
-	return nil
+	return output, nil
 }
@@ -0,0 +1,114 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestChromeFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cfg      func(cfg *ChromeConfig)
+		expected map[string]any
+	}{
+		{
+			name: "defaults",
+			cfg:  func(*ChromeConfig) {},
+			expected: map[string]any{
+				"headless": true, "user-agent": defaultUserAgent,
+				"disable-gpu": true, "no-sandbox": true, "window-size": "1920,1080",
+			},
+		},
+		{
+			name: "zero value",
+			cfg:  func(cfg *ChromeConfig) { *cfg = ChromeConfig{} }, //nolint:exhaustruct // zero value under test
+			expected: map[string]any{
+				"headless": true, "user-agent": defaultUserAgent,
+				"disable-gpu": true, "no-sandbox": true, "window-size": "1920,1080",
+			},
+		},
+		{
+			name: "headful with custom flags",
+			cfg: func(cfg *ChromeConfig) {
+				cfg.Headful = true
+				cfg.Flags = map[string]any{"no-sandbox": false, "lang": "de-DE"}
+			},
+			expected: map[string]any{
+				"headless": false, "user-agent": defaultUserAgent, "no-sandbox": false, "lang": "de-DE",
+			},
+		},
+		{
+			name: "user agent flag",
+			cfg: func(cfg *ChromeConfig) {
+				cfg.Flags = map[string]any{"user-agent": "custom-agent/1.0"}
+			},
+			expected: map[string]any{"headless": true, "user-agent": "custom-agent/1.0"},
+		},
+		{
+			name: "proxy and profile",
+			cfg: func(cfg *ChromeConfig) {
+				cfg.Flags = map[string]any{"proxy-server": "http://ignored:1"}
+				cfg.Proxy = "socks5://127.0.0.1:1080"
+				cfg.UserDataDir = "/tmp/profile"
+			},
+			expected: map[string]any{
+				"headless": true, "user-agent": defaultUserAgent,
+				"proxy-server": "socks5://127.0.0.1:1080", "user-data-dir": "/tmp/profile",
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultChromeConfig()
+			testCase.cfg(&cfg)
+
+			if got := chromeFlags(cfg); !maps.Equal(got, testCase.expected) {
+				t.Errorf("chromeFlags() = %v, want %v", got, testCase.expected)
+			}
+
+			// Every flag is passed on top of the chromedp defaults
+			minOptions := len(chromedp.DefaultExecAllocatorOptions) + len(testCase.expected)
+			if got := len(chromeAllocatorOptions(cfg)); got < minOptions {
+				t.Errorf("chromeAllocatorOptions() has %d options, want at least %d", got, minOptions)
+			}
+		})
+	}
+}
+
+func TestBrowserPoolAllocatesRemoteBrowsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		remoteURL string
+		remote    bool
+	}{
+		{"local", "", false},
+		{"remote", "ws://127.0.0.1:9222/devtools/browser/id", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultChromeConfig()
+			cfg.RemoteURL = testCase.remoteURL
+
+			allocCtx, cancel := newBrowserPool(cfg).allocate()
+			defer cancel()
+
+			_, remote := chromedp.FromContext(allocCtx).Allocator.(*chromedp.RemoteAllocator)
+			if remote != testCase.remote {
+				t.Errorf("allocate() uses a remote allocator = %v, want %v", remote, testCase.remote)
+			}
+		})
+	}
+}
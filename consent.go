@@ -0,0 +1,265 @@
+package emailscraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// defaultConsentTimeout is how long a rendered page may load after its consent prompt was dismissed.
+const defaultConsentTimeout = 3 * time.Second
+
+// consentFrameworkSelectors match the "accept" buttons of common consent management platforms.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var consentFrameworkSelectors = []string{
+	"#onetrust-accept-btn-handler",                           // OneTrust
+	"#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll", // Cookiebot
+	"#CybotCookiebotDialogBodyButtonAccept",                  // Cookiebot (legacy)
+	"#didomi-notice-agree-button",                            // Didomi
+	`.qc-cmp2-summary-buttons button[mode="primary"]`,        // Quantcast Choice
+	"#truste-consent-button",                                 // TrustArc
+	`[data-testid="uc-accept-all-button"]`,                   // Usercentrics
+	".cky-btn-accept",                                        // CookieYes
+	".osano-cm-accept-all",                                   // Osano
+	".cmplz-accept",                                          // Complianz
+	".iubenda-cs-accept-btn",                                 // iubenda
+	".cm-btn-accept-all",                                     // Klaro
+	`[data-tid="banner-accept"]`,                             // Termly
+	"._brlbs-btn-accept-all",                                 // Borlabs Cookie
+	"#axeptio_btn_acceptAll",                                 // Axeptio
+	".fc-cta-consent",                                        // Google Funding Choices
+	"#cn-accept-cookie",                                      // Cookie Notice
+	"#cookie_action_close_header",                            // CookieLawInfo
+	".moove-gdpr-infobar-allow-all",                          // GDPR Cookie Compliance
+	".cc-window .cc-allow, .cc-window .cc-dismiss",           // Osano cookieconsent
+}
+
+// consentAcceptPhrases are the lower-case labels of buttons that accept a consent prompt.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var consentAcceptPhrases = []string{
+	// English
+	"accept all", "accept all cookies", "accept cookies", "accept", "allow all", "allow cookies", "allow",
+	"agree", "i agree", "i accept", "agree and close", "got it", "ok", "okay", "understood",
+	// German
+	"alle akzeptieren", "akzeptieren", "alle zulassen", "zustimmen", "allen zustimmen", "einverstanden",
+	// French
+	"tout accepter", "accepter", "accepter et fermer", "j'accepte", "d'accord",
+	// Spanish and Portuguese
+	"aceptar todo", "aceptar todas", "aceptar", "acepto", "aceitar todos", "aceitar", "aceito", "concordo",
+	// Italian
+	"accetta tutti", "accetta tutto", "accetta", "accetto",
+	// Dutch
+	"alles accepteren", "accepteren", "akkoord",
+	// Nordic
+	"godkänn alla", "acceptera alla", "acceptera", "accepter alle", "godta alle", "hyväksy kaikki", "hyväksy",
+	// Central and Eastern European
+	"zaakceptuj wszystkie", "akceptuję", "zgadzam się", "přijmout vše", "souhlasím", "elfogadom",
+	"принять все", "принять", "согласен", "прийняти",
+	// Other
+	"kabul et", "同意", "同意する", "すべて同意", "接受", "全部接受", "동의", "모두 동의",
+}
+
+// consentRejectPhrases are the lower-case labels of buttons that decline or close a consent prompt.
+// They are only used when no accept button is found.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var consentRejectPhrases = []string{
+	"reject all", "reject", "decline", "deny", "necessary only", "only necessary", "essential only", "close",
+	"alle ablehnen", "ablehnen", "nur notwendige", "schließen",
+	"tout refuser", "refuser", "continuer sans accepter", "fermer",
+	"rechazar todo", "rechazar", "rejeitar", "recusar", "cerrar", "fechar",
+	"rifiuta tutto", "rifiuta", "chiudi",
+	"alles weigeren", "weigeren", "sluiten",
+	"avvisa alla", "avvisa", "afvis", "hylkää",
+	"odrzuć", "odmítnout", "отклонить", "закрыть",
+}
+
+// consentConfig is the part of the consent handler that is passed to the page as JSON.
+type consentConfig struct {
+	Custom     []string `json:"custom"`
+	Frameworks []string `json:"frameworks"`
+	Accept     []string `json:"accept"`
+	Reject     []string `json:"reject"`
+}
+
+// consentScriptTemplate dismisses consent prompts and evaluates to the number of buttons clicked and
+// overlays removed. It clicks the first visible match of every custom selector, then the first known
+// framework button and, when neither matched, a labelled accept (or else reject) button inside an
+// element that looks like a consent prompt. Open shadow roots are searched as well. Links that lead
+// elsewhere are never clicked, and only fixed overlays named like consent prompts that hold a small
+// part of the page are removed, so fixed full-screen layouts survive.
+const consentScriptTemplate = `((config) => {
+	const hint = new RegExp([
+		"cookie", "consent", "gdpr", "ccpa", "privacy", "datenschutz", "cmp", "didomi", "onetrust", "cybot",
+		"usercentrics", "truste", "sp_message", "osano", "iubenda", "klaro", "termly", "cky-", "cmplz", "borlabs",
+		"axeptio",
+	].join("|"), "i");
+	const clickable = 'button, a, [role="button"], input[type="button"], input[type="submit"]';
+
+	const roots = [document];
+	for (let i = 0; i < roots.length; i++) {
+		for (const element of roots[i].querySelectorAll("*")) {
+			if (element.shadowRoot) roots.push(element.shadowRoot);
+		}
+	}
+
+	const queryAll = (selector) => roots.flatMap((root) => {
+		try {
+			return Array.from(root.querySelectorAll(selector));
+		} catch (error) {
+			return [];
+		}
+	});
+
+	const visible = (element) => {
+		const rect = element.getBoundingClientRect();
+		const style = getComputedStyle(element);
+
+		return rect.width > 0 && rect.height > 0 && style.visibility !== "hidden" && style.display !== "none";
+	};
+
+	const describe = (element) => [
+		element.id,
+		typeof element.className === "string" ? element.className : "",
+		element.getAttribute("aria-label") || "",
+		element.getAttribute("data-testid") || "",
+	].join(" ");
+
+	const parentOf = (node) => {
+		if (node.parentElement) return node.parentElement;
+		const root = node.getRootNode();
+
+		return root instanceof ShadowRoot ? root.host : null;
+	};
+
+	const inPrompt = (element) => {
+		for (let node = element; node; node = parentOf(node)) {
+			if (hint.test(describe(node))) return true;
+			const role = node.getAttribute("role");
+			if ((role === "dialog" || role === "alertdialog" || node.getAttribute("aria-modal") === "true") &&
+				/cookie/i.test(node.textContent)) return true;
+		}
+
+		return false;
+	};
+
+	const label = (element) => (element.innerText || element.value || element.getAttribute("aria-label") || "")
+		.trim().toLowerCase().replace(/\s+/g, " ");
+
+	const labelled = (text, phrases) => text.length <= 40 && phrases.some((phrase) =>
+		text === phrase || (text.startsWith(phrase) && /^[\s.,!:;)\-–—]/.test(text.slice(phrase.length))));
+
+	let dismissed = 0;
+
+	// Links with a real target would navigate away from the page instead of dismissing the prompt
+	const staysOnPage = (element) => {
+		const href = element.tagName === "A" ? (element.getAttribute("href") || "").trim() : "";
+
+		return href === "" || href.startsWith("#") || /^javascript:/i.test(href);
+	};
+
+	const clickFirst = (selector, custom) => {
+		const element = queryAll(selector).find((candidate) => visible(candidate) && (custom || staysOnPage(candidate)));
+		if (!element) return false;
+		element.click();
+		dismissed++;
+
+		return true;
+	};
+
+	config.custom.forEach((selector) => clickFirst(selector, true));
+	config.frameworks.some((selector) => clickFirst(selector, false));
+
+	if (dismissed === 0) {
+		const buttons = queryAll(clickable).filter((element) =>
+			visible(element) && staysOnPage(element) && inPrompt(element));
+		const button = buttons.find((element) => labelled(label(element), config.accept)) ||
+			buttons.find((element) => labelled(label(element), config.reject));
+		if (button) {
+			button.click();
+			dismissed++;
+		}
+	}
+
+	// Prompts that stay in front of the content are removed. Fixed elements that hold most of the page
+	// are the page itself, e.g. an app with a fixed full-screen layout, however they are named.
+	const pageText = (document.body.innerText || "").length;
+	const overlayOnly = (element) => hint.test(describe(element)) && !element.querySelector("main, article") &&
+		(element.innerText || "").length * 2 < pageText;
+
+	for (const element of document.elementsFromPoint(innerWidth / 2, innerHeight / 2)) {
+		let overlay = element;
+		while (overlay && getComputedStyle(overlay).position !== "fixed") overlay = overlay.parentElement;
+		if (!overlay || overlay === document.documentElement || overlay === document.body || !overlay.isConnected) continue;
+		if (overlayOnly(overlay)) {
+			overlay.remove();
+			dismissed++;
+		}
+	}
+
+	// Consent walls usually lock scrolling of the page
+	if (dismissed > 0) {
+		for (const element of [document.documentElement, document.body]) {
+			if (getComputedStyle(element).overflow === "hidden") {
+				element.style.setProperty("overflow", "visible", "important");
+			}
+		}
+	}
+
+	return dismissed;
+})(%s)`
+
+// consentScript returns the consent handler script; extra selectors are clicked before the built-in ones.
+func consentScript(extra []string) (string, error) {
+	config, err := json.Marshal(consentConfig{
+		Custom:     append(make([]string, 0, len(extra)), extra...),
+		Frameworks: consentFrameworkSelectors,
+		Accept:     consentAcceptPhrases,
+		Reject:     consentRejectPhrases,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding consent config: %w", err)
+	}
+
+	return fmt.Sprintf(consentScriptTemplate, config), nil
+}
+
+// dismissConsent returns an action that dismisses cookie consent prompts and overlays covering the page.
+// Pages without a prompt, or with one the handler does not recognize, are captured as they are.
+func dismissConsent(extra []string, tracker *networkTracker) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		script, err := consentScript(extra)
+		if err != nil {
+			return err
+		}
+
+		var dismissed int
+
+		err = chromedp.Evaluate(script, &dismissed).Do(ctx)
+		if ctx.Err() != nil {
+			return fmt.Errorf("dismissing consent prompt: %w", ctx.Err())
+		}
+
+		if err != nil || dismissed == 0 {
+			return nil
+		}
+
+		// Consenting often loads the withheld content or reloads the page
+		waitCtx, cancel := context.WithTimeout(ctx, defaultConsentTimeout)
+		defer cancel()
+
+		_ = tracker.waitIdle(defaultNetworkIdleTime)(waitCtx)
+
+		err = chromedp.WaitReady("body", chromedp.ByQuery).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("waiting for page after consent: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dop251/goja"
+)
+
+func TestConsentScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		extra        []string
+		wantContains []string
+	}{
+		{
+			name:         "built-in only",
+			extra:        nil,
+			wantContains: []string{`"custom":[]`, "#onetrust-accept-btn-handler", `"alle akzeptieren"`},
+		},
+		{
+			name:         "custom selectors",
+			extra:        []string{"#close-newsletter", `button[data-action="agree"]`},
+			wantContains: []string{`"custom":["#close-newsletter","button[data-action=\"agree\"]"]`},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := consentScript(testCase.extra)
+			if err != nil {
+				t.Fatalf("consentScript() error = %v", err)
+			}
+
+			for _, want := range testCase.wantContains {
+				if !strings.Contains(script, want) {
+					t.Errorf("consentScript() does not contain %q", want)
+				}
+			}
+
+			if _, err := goja.Compile("consent", script, false); err != nil {
+				t.Errorf("consentScript() is not valid JavaScript: %v", err)
+			}
+		})
+	}
+}
+
+func TestConsentPhrasesAreNormalized(t *testing.T) {
+	t.Parallel()
+
+	// Button labels are lower-cased and whitespace-collapsed before they are compared
+	for _, phrases := range [][]string{consentAcceptPhrases, consentRejectPhrases} {
+		for _, phrase := range phrases {
+			if phrase != strings.ToLower(strings.Join(strings.Fields(phrase), " ")) {
+				t.Errorf("phrase %q is not normalized", phrase)
+			}
+		}
+	}
+}
+
+func TestDismissConsentIsOptIn(t *testing.T) {
+	t.Parallel()
+
+	// Clicking and removing elements changes rendered pages, so callers have to ask for it
+	if DefaultChromeConfig().DismissConsent {
+		t.Error("DefaultChromeConfig() dismisses consent prompts")
+	}
+}
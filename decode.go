@@ -0,0 +1,42 @@
+package emailscraper
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// Matches HTML character references that can hide the characters of an address.
+	characterReferences = regexp.MustCompile(`&(#[0-9]+|#[xX][0-9a-fA-F]+|commat|period|hyphen|dash|lowbar);?`)
+
+	// Matches a single percent-encoded byte.
+	percentEncodedByte = regexp.MustCompile(`%[0-9a-fA-F]{2}`)
+)
+
+// decodeCharacterReferences decodes the HTML character references of body, e.g. &#105;&#110;&#102;&#111;&#64;
+// as written by WordPress' antispambot or &commat;. It reports false when body has nothing to decode.
+func decodeCharacterReferences(body []byte) ([]byte, bool) {
+	if !characterReferences.Match(body) {
+		return nil, false
+	}
+
+	return []byte(html.UnescapeString(string(body))), true
+}
+
+// decodePercentEncoding decodes percent-encoded bytes, e.g. info%40example.com in links. It reports false
+// when body has nothing to decode. Malformed escapes are kept as they are.
+func decodePercentEncoding(body []byte) ([]byte, bool) {
+	if !percentEncodedByte.Match(body) {
+		return nil, false
+	}
+
+	return percentEncodedByte.ReplaceAllFunc(body, func(escape []byte) []byte {
+		value, err := strconv.ParseUint(string(escape[1:]), 16, 8)
+		if err != nil {
+			return escape
+		}
+
+		return []byte{byte(value)}
+	}), true
+}
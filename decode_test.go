@@ -0,0 +1,94 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"testing"
+)
+
+func TestDecodeCharacterReferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		wantOK bool
+	}{
+		{"decimal", "&#105;&#110;&#102;&#111;&#64;example.com", "info@example.com", true},
+		{"hex", "&#x69;nfo&#X40;example&#x2e;com", "info@example.com", true},
+		{"named", "info&commat;example&period;com", "info@example.com", true},
+		{"mixed with markup", "<b>a&#64;b.io</b> &amp; more", "<b>a@b.io</b> & more", true},
+		{"nothing to decode", "info at example &amp; co", "", false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := decodeCharacterReferences([]byte(testCase.body))
+			if ok != testCase.wantOK || string(got) != testCase.want {
+				t.Errorf("decodeCharacterReferences(%q) = %q, %v, want %q, %v",
+					testCase.body, got, ok, testCase.want, testCase.wantOK)
+			}
+		})
+	}
+}
+
+func TestDecodePercentEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		wantOK bool
+	}{
+		{"link", `href="/contact?email=info%40example.com"`, `href="/contact?email=info@example.com"`, true},
+		{"encoded dots", "j%2Edoe%40example%2ecom", "j.doe@example.com", true},
+		{"malformed escapes kept", "100% sure: a%40b.io %zz", "100% sure: a@b.io %zz", true},
+		{"nothing encoded", "100% sure", "", false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := decodePercentEncoding([]byte(testCase.body))
+			if ok != testCase.wantOK || string(got) != testCase.want {
+				t.Errorf("decodePercentEncoding(%q) = %q, %v, want %q, %v", testCase.body, got, ok, testCase.want, testCase.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseEmailsDecodingMethods(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantEmail  string
+		wantMethod Method
+	}{
+		{"antispambot", "<a>&#115;&#97;&#108;&#101;&#115;&#64;example.com</a>", "sales@example.com", MethodHTMLEntity},
+		{"commat", "press&commat;example.org", "press@example.org", MethodHTMLEntity},
+		{"percent-encoded link", `<a href="/share?to=team%40example.net">`, "team@example.net", MethodPercentEncoded},
+		{"escape inside entities", "j%2Edoe&#64;example.com", "j.doe@example.com", MethodPercentEncoded},
+		{"plain wins", "hi@example.com &#64;", "hi@example.com", MethodRegex},
+		{"encoded separator", "help&#91;at&#93;example.com", "help@example.com", MethodDeobfuscated},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			emailSet := &emails{} //nolint:exhaustruct // zero value is valid
+			emailSet.parseEmails([]byte(testCase.body), testOrigin())
+
+			findings := emailSet.findings()
+			if len(findings) != 1 || findings[0].Email != testCase.wantEmail || findings[0].Method != testCase.wantMethod {
+				t.Errorf("parseEmails(%q) = %+v, want %s via %s", testCase.body, findings, testCase.wantEmail, testCase.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,537 @@
+package emailscraper
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// minDeobfuscationConfidence is the confidence below which reconstructed addresses are dropped.
+	minDeobfuscationConfidence = 0.3
+	// deobfuscationWindowBefore is the amount of text before an "at" separator tokenized for the local part.
+	deobfuscationWindowBefore = 256
+	// deobfuscationWindowAfter is the amount of text after an "at" separator tokenized for the domain.
+	deobfuscationWindowAfter = 512
+
+	// atLookalikes are the characters written for "@", the ASCII one included.
+	atLookalikes = "@＠﹫"
+	// dotLookalikes are the characters written for ".", the ASCII one included.
+	dotLookalikes = ".．。｡﹒"
+	// openingBrackets and closingBrackets pair up the brackets written around separators.
+	openingBrackets = "[({<［（｛〔【"
+	closingBrackets = "])}>］）｝〕】"
+)
+
+// Confidence of a reconstruction, by how its "at" separator is written.
+const (
+	confidenceSymbolAt    = 1.0 // user@example [dot] com
+	confidenceSpacedAt    = 0.9 // user @ example.com
+	confidenceBracketedAt = 0.9 // user[at]example.com, user (at) example.com
+	confidenceUpperWordAt = 0.8 // user AT example.com
+	confidenceWordAt      = 0.5 // user at example dot com
+	// confidenceWordAtPlainDots is below minDeobfuscationConfidence: a lowercase word before a plain
+	// domain is mostly prose, e.g. "Read more at nytimes.com", so it only counts with obfuscated dots.
+	confidenceWordAtPlainDots = 0.2 // user at example.com
+	// confidenceObfuscatedDotBonus is added when the domain dots are obfuscated as well, a sign of intent.
+	confidenceObfuscatedDotBonus = 0.15
+	// confidenceStopwordFactor scales reconstructions whose local part is a common word, e.g. "us at example.com".
+	confidenceStopwordFactor = 0.3
+)
+
+// localPartStopwords are words that precede a plain "at" in prose far more often than they name a mailbox.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var localPartStopwords = map[string]struct{}{
+	"us": {}, "me": {}, "we": {}, "you": {}, "him": {}, "her": {}, "them": {}, "it": {}, "is": {}, "are": {},
+	"was": {}, "were": {}, "be": {}, "been": {}, "look": {}, "looking": {}, "see": {}, "meet": {}, "stay": {},
+	"arrive": {}, "arrived": {}, "available": {}, "located": {}, "based": {}, "here": {}, "there": {},
+	"home": {}, "work": {}, "call": {}, "email": {}, "mail": {}, "write": {}, "contact": {}, "reach": {},
+	"visit": {}, "found": {}, "online": {}, "open": {}, "starts": {}, "begins": {}, "only": {}, "least": {},
+	"works": {}, "worked": {}, "working": {}, "job": {}, "studied": {}, "studies": {}, "live": {}, "lives": {},
+	"lived": {}, "buy": {}, "order": {}, "sold": {}, "listed": {}, "hosted": {}, "published": {}, "posted": {},
+}
+
+// defaultDeobfuscator reconstructs addresses written with the default vocabularies.
+//
+//nolint:gochecknoglobals // immutable after initialization
+var defaultDeobfuscator = newDeobfuscator(DefaultSeparatorVocabularies())
+
+// tokenKind classifies the tokens of obfuscated text.
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenSpace
+	tokenAt
+	tokenDot
+	tokenOther
+)
+
+// separatorForm is how an "at" or "dot" separator is written.
+type separatorForm int
+
+const (
+	// formSymbol is the separator character itself, "@" or ".", or a look-alike such as "＠".
+	formSymbol separatorForm = iota
+	// formBracketed is a word or symbol in brackets, e.g. [at], (dot), {@}.
+	formBracketed
+	// formWord is a bare word, e.g. at, DOT, punto. It only separates when surrounded by spaces.
+	formWord
+)
+
+// token is a piece of tokenized text; start and end are byte offsets into the tokenized text.
+type token struct {
+	kind tokenKind
+	form separatorForm
+	// lookalike marks separators written with a look-alike character, such as "＠" for "@".
+	lookalike  bool
+	text       string
+	start, end int
+}
+
+// deobfuscator reconstructs addresses whose "@" and "." are written as words or bracketed words.
+type deobfuscator struct {
+	atWords    map[string]struct{}
+	dotWords   map[string]struct{}
+	candidates *regexp.Regexp
+}
+
+// reconstruction is an address rebuilt from obfuscated text.
+type reconstruction struct {
+	email      string
+	confidence float64
+	start, end int
+}
+
+// newDeobfuscator returns a deobfuscator that understands the separator words of every vocabulary.
+func newDeobfuscator(vocabularies []SeparatorVocabulary) *deobfuscator {
+	d := &deobfuscator{
+		atWords:    make(map[string]struct{}),
+		dotWords:   make(map[string]struct{}),
+		candidates: nil,
+	}
+
+	alternatives := make([]string, 0)
+
+	for _, vocabulary := range vocabularies {
+		for _, word := range vocabulary.At {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if _, seen := d.atWords[word]; word == "" || seen {
+				continue
+			}
+
+			d.atWords[word] = struct{}{}
+			alternatives = append(alternatives, regexp.QuoteMeta(word))
+		}
+
+		for _, word := range vocabulary.Dot {
+			if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+				d.dotWords[word] = struct{}{}
+			}
+		}
+	}
+
+	// Separator words are only candidates as whole words, "at" inside "data" is not
+	pattern := `[` + atLookalikes + `]`
+	if len(alternatives) > 0 {
+		pattern += `|(?i)(?:^|[^\p{L}\p{N}])(?:` + strings.Join(alternatives, "|") + `)(?:[^\p{L}\p{N}]|$)`
+	}
+
+	d.candidates = regexp.MustCompile(pattern)
+
+	return d
+}
+
+// reconstruct returns the addresses written with obfuscated separators in text. Addresses written
+// plainly are left to the regular expression.
+func (d *deobfuscator) reconstruct(text string) []reconstruction {
+	found := make([]reconstruction, 0)
+
+	for _, window := range d.windows(text) {
+		tokens := d.tokenize(text[window[0]:window[1]], window[0], window[0] > 0, window[1] < len(text))
+
+		for i := range tokens {
+			if tokens[i].kind != tokenAt {
+				continue
+			}
+
+			if rebuilt, ok := d.reconstructAt(tokens, i); ok {
+				found = append(found, rebuilt)
+			}
+		}
+	}
+
+	return found
+}
+
+// windows returns the merged ranges of text around every "at" candidate.
+func (d *deobfuscator) windows(text string) [][2]int {
+	windows := make([][2]int, 0)
+
+	for _, loc := range d.candidates.FindAllStringIndex(text, -1) {
+		start := alignRuneStart(text, max(loc[0]-deobfuscationWindowBefore, 0))
+		end := alignRuneStart(text, min(loc[1]+deobfuscationWindowAfter, len(text)))
+
+		if last := len(windows) - 1; last >= 0 && start <= windows[last][1] {
+			windows[last][1] = end
+
+			continue
+		}
+
+		windows = append(windows, [2]int{start, end})
+	}
+
+	return windows
+}
+
+// alignRuneStart moves offset back to the start of the rune it points into.
+func alignRuneStart(text string, offset int) int {
+	for offset > 0 && offset < len(text) && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+
+	return offset
+}
+
+// tokenize splits text into words, spaces, separators and other characters. Offsets are shifted by base.
+// Words cut by the window edges are marked as other characters so they never form partial addresses.
+func (d *deobfuscator) tokenize(text string, base int, cutStart, cutEnd bool) []token {
+	tokens := make([]token, 0)
+
+	for pos := 0; pos < len(text); {
+		char, size := utf8.DecodeRuneInString(text[pos:])
+		next := token{
+			kind:      tokenOther,
+			form:      formSymbol,
+			lookalike: false,
+			text:      text[pos : pos+size],
+			start:     pos,
+			end:       pos + size,
+		}
+
+		switch {
+		case unicode.IsSpace(char):
+			next.kind = tokenSpace
+			next.end = scanWhile(text, pos, unicode.IsSpace)
+		case isWordRune(char):
+			next.end = scanWhile(text, pos, isWordRune)
+			next.kind, next.form = d.classifyWord(text[pos:next.end])
+		case strings.ContainsRune(atLookalikes, char):
+			next.kind = tokenAt
+			next.lookalike = char != '@'
+		case strings.ContainsRune(dotLookalikes, char):
+			next.kind = tokenDot
+			next.lookalike = char != '.'
+		case strings.ContainsRune(openingBrackets, char):
+			if bracketed, ok := d.bracketed(text, pos); ok {
+				next = bracketed
+			}
+		}
+
+		next.text = text[next.start:next.end]
+		pos = next.end
+
+		tokens = append(tokens, next)
+	}
+
+	if cutStart && len(tokens) > 0 && tokens[0].kind == tokenWord {
+		tokens[0].kind = tokenOther
+	}
+
+	if last := len(tokens) - 1; cutEnd && last >= 0 && tokens[last].kind == tokenWord {
+		tokens[last].kind = tokenOther
+	}
+
+	for i := range tokens {
+		tokens[i].start += base
+		tokens[i].end += base
+	}
+
+	return tokens
+}
+
+// classifyWord tells separator words apart from ordinary words.
+func (d *deobfuscator) classifyWord(word string) (tokenKind, separatorForm) {
+	lower := strings.ToLower(word)
+
+	if _, ok := d.atWords[lower]; ok {
+		return tokenAt, formWord
+	}
+
+	if _, ok := d.dotWords[lower]; ok {
+		return tokenDot, formWord
+	}
+
+	return tokenWord, formSymbol
+}
+
+// bracketed parses a bracketed separator such as "[at]", "( dot )" or "{@}" starting at text[pos].
+func (d *deobfuscator) bracketed(text string, pos int) (token, bool) {
+	opening, size := utf8.DecodeRuneInString(text[pos:])
+
+	inner := scanWhile(text, pos+size, unicode.IsSpace)
+	wordEnd := scanWhile(text, inner, isWordRune)
+
+	if wordEnd == inner && inner < len(text) {
+		char, charSize := utf8.DecodeRuneInString(text[inner:])
+		if strings.ContainsRune(atLookalikes+dotLookalikes, char) {
+			wordEnd = inner + charSize
+		}
+	}
+
+	end := scanWhile(text, wordEnd, unicode.IsSpace)
+	if wordEnd == inner || end >= len(text) {
+		return token{}, false //nolint:exhaustruct // not used when false
+	}
+
+	closing, closingSize := utf8.DecodeRuneInString(text[end:])
+	if closing != closingBracket(opening) {
+		return token{}, false //nolint:exhaustruct // not used when false
+	}
+
+	var kind tokenKind
+
+	switch word := text[inner:wordEnd]; {
+	case strings.Contains(atLookalikes, word):
+		kind = tokenAt
+	case strings.Contains(dotLookalikes, word):
+		kind = tokenDot
+	default:
+		kind, _ = d.classifyWord(word)
+		if kind == tokenWord {
+			return token{}, false //nolint:exhaustruct // not used when false
+		}
+	}
+
+	return token{
+		kind:      kind,
+		form:      formBracketed,
+		lookalike: false,
+		text:      text[pos : end+closingSize],
+		start:     pos,
+		end:       end + closingSize,
+	}, true
+}
+
+// closingBracket returns the bracket closing opening.
+func closingBracket(opening rune) rune {
+	closers := []rune(closingBrackets)
+
+	for i, char := range []rune(openingBrackets) {
+		if char == opening {
+			return closers[i]
+		}
+	}
+
+	return opening
+}
+
+// scanWhile returns the offset of the first rune at or after pos that does not satisfy accept.
+func scanWhile(text string, pos int, accept func(rune) bool) int {
+	for pos < len(text) {
+		char, size := utf8.DecodeRuneInString(text[pos:])
+		if !accept(char) {
+			break
+		}
+
+		pos += size
+	}
+
+	return pos
+}
+
+// isWordRune reports whether char can be part of an address word.
+func isWordRune(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || strings.ContainsRune("_%+-", char)
+}
+
+// reconstructAt rebuilds the address around the "at" separator tokens[at].
+func (d *deobfuscator) reconstructAt(tokens []token, at int) (reconstruction, bool) {
+	var none reconstruction
+
+	spacedBefore := at > 0 && tokens[at-1].kind == tokenSpace
+	spacedAfter := at+1 < len(tokens) && tokens[at+1].kind == tokenSpace
+
+	// A bare word only separates when it stands alone, "example.at" is a domain
+	if tokens[at].form == formWord && (!spacedBefore || !spacedAfter) {
+		return none, false
+	}
+
+	local, localStart, localObfuscated := readLocalPart(tokens, skipSpaces(tokens, at-1, -1))
+	labels, domainEnd, domainObfuscated := readDomain(tokens, skipSpaces(tokens, at+1, 1))
+
+	labels = trimToValidTLD(labels)
+	if local == "" || len(labels) < minEmailDomainParts || !plausibleLocalPart(local) {
+		return none, false
+	}
+
+	// Plain addresses are matched by the regular expression
+	if tokens[at].form == formSymbol && !tokens[at].lookalike && !spacedBefore && !spacedAfter &&
+		!localObfuscated && !domainObfuscated {
+		return none, false
+	}
+
+	confidence := atConfidence(tokens[at], spacedBefore || spacedAfter, domainObfuscated || localObfuscated)
+	if domainObfuscated || localObfuscated {
+		confidence = min(confidence+confidenceObfuscatedDotBonus, 1)
+	}
+
+	if _, ok := localPartStopwords[strings.ToLower(local)]; ok && tokens[at].form == formWord {
+		confidence *= confidenceStopwordFactor
+	}
+
+	if confidence < minDeobfuscationConfidence {
+		return none, false
+	}
+
+	return reconstruction{
+		email:      local + "@" + strings.Join(labels, "."),
+		confidence: confidence,
+		start:      tokens[localStart].start,
+		end:        tokens[domainEnd].end,
+	}, true
+}
+
+// atConfidence returns the base confidence of an "at" separator, given whether it is surrounded by
+// spaces and whether the dots of the address are obfuscated as well.
+func atConfidence(at token, spaced, dotsObfuscated bool) float64 {
+	switch {
+	case at.form == formBracketed:
+		return confidenceBracketedAt
+	case at.form == formSymbol && spaced:
+		return confidenceSpacedAt
+	case at.form == formSymbol:
+		return confidenceSymbolAt
+	case at.text == strings.ToUpper(at.text):
+		return confidenceUpperWordAt
+	case dotsObfuscated:
+		return confidenceWordAt
+	default:
+		return confidenceWordAtPlainDots
+	}
+}
+
+// skipSpaces returns the index of the first non-space token from i in direction step.
+func skipSpaces(tokens []token, i, step int) int {
+	for i >= 0 && i < len(tokens) && tokens[i].kind == tokenSpace {
+		i += step
+	}
+
+	return i
+}
+
+// dotAt reports whether tokens[i] separates two words as a dot, and whether that dot is obfuscated.
+// Literal dots must touch both words; spaced or worded dots must be surrounded by spaces.
+func dotAt(tokens []token, i int) (isDot, obfuscated bool) {
+	if i <= 0 || i >= len(tokens)-1 || tokens[i].kind != tokenDot {
+		return false, false
+	}
+
+	spaced := tokens[i-1].kind == tokenSpace && tokens[i+1].kind == tokenSpace
+	touching := tokens[i-1].kind == tokenWord && tokens[i+1].kind == tokenWord
+
+	switch tokens[i].form {
+	case formSymbol:
+		return touching || spaced, spaced || tokens[i].lookalike
+	case formBracketed:
+		return true, true
+	case formWord:
+		return spaced, true
+	default:
+		return false, false
+	}
+}
+
+// readLocalPart reads the local part ending at tokens[end], walking backwards over dot separators.
+func readLocalPart(tokens []token, end int) (string, int, bool) {
+	if end < 0 || end >= len(tokens) || tokens[end].kind != tokenWord {
+		return "", 0, false
+	}
+
+	words := []string{tokens[end].text}
+	start, obfuscated := end, false
+
+	for {
+		dot := skipSpaces(tokens, start-1, -1)
+
+		isDot, dotObfuscated := dotAt(tokens, dot)
+		if !isDot {
+			break
+		}
+
+		word := skipSpaces(tokens, dot-1, -1)
+		if word < 0 || tokens[word].kind != tokenWord {
+			break
+		}
+
+		words = append([]string{tokens[word].text}, words...)
+		start, obfuscated = word, obfuscated || dotObfuscated
+	}
+
+	return strings.Join(words, "."), start, obfuscated
+}
+
+// readDomain reads the domain labels starting at tokens[start], walking forwards over dot separators.
+func readDomain(tokens []token, start int) ([]string, int, bool) {
+	if start < 0 || start >= len(tokens) || tokens[start].kind != tokenWord {
+		return nil, 0, false
+	}
+
+	labels := []string{tokens[start].text}
+	end, obfuscated := start, false
+
+	for {
+		dot := skipSpaces(tokens, end+1, 1)
+
+		isDot, dotObfuscated := dotAt(tokens, dot)
+		if !isDot {
+			break
+		}
+
+		word := skipSpaces(tokens, dot+1, 1)
+		if word >= len(tokens) || tokens[word].kind != tokenWord || !plausibleDomainLabel(tokens[word].text) {
+			break
+		}
+
+		labels = append(labels, tokens[word].text)
+		end, obfuscated = word, obfuscated || dotObfuscated
+	}
+
+	if !plausibleDomainLabel(labels[0]) {
+		return nil, 0, false
+	}
+
+	return labels, end, obfuscated
+}
+
+// trimToValidTLD drops trailing labels until the last one is a known top-level domain, so that words
+// following an address in prose ("example dot com dot Thanks") are not taken for part of it.
+func trimToValidTLD(labels []string) []string {
+	for len(labels) >= minEmailDomainParts && !validTLD(labels[len(labels)-1]) {
+		labels = labels[:len(labels)-1]
+	}
+
+	return labels
+}
+
+// plausibleLocalPart reports whether local can be the local part of an address.
+func plausibleLocalPart(local string) bool {
+	if len(local) > maxLocalPartLength || strings.Trim(local, "._%+-") != local {
+		return false
+	}
+
+	return strings.IndexFunc(local, func(char rune) bool { return unicode.IsLetter(char) || unicode.IsDigit(char) }) >= 0
+}
+
+// plausibleDomainLabel reports whether label can be a domain label: letters, digits and inner hyphens.
+func plausibleDomainLabel(label string) bool {
+	if label == "" || len(label) > maxDomainLabelLength || strings.Trim(label, "-") != label {
+		return false
+	}
+
+	return strings.IndexFunc(label, func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '-'
+	}) < 0
+}
@@ -0,0 +1,147 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDeobfuscatorReconstruct(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		text           string
+		wantEmail      string
+		wantConfidence float64
+	}{
+		{"bracketed at", "Email: user[AT]domain.com", "user@domain.com", confidenceBracketedAt},
+		{"parenthesized at", "Email: user(at)domain.com", "user@domain.com", confidenceBracketedAt},
+		{"spaced brackets", "jane ( at ) example.org", "jane@example.org", confidenceBracketedAt},
+		{"upper-case word", "Email: user AT domain.com", "user@domain.com", confidenceUpperWordAt},
+		{
+			"words for at and dot",
+			"write to john at example dot com today",
+			"john@example.com",
+			confidenceWordAt + confidenceObfuscatedDotBonus,
+		},
+		{"bracketed dots", "info [at] mail [dot] example [dot] co [dot] uk", "info@mail.example.co.uk", 1},
+		{"punto", "ventas(at)empresa(punto)es", "ventas@empresa.es", 1},
+		{"dotted local part", "first [dot] last [at] example [dot] net", "first.last@example.net", 1},
+		{"spaced symbols", "sales @ example . com", "sales@example.com", 1},
+		{"plain at, obfuscated dot", "help@example(dot)io", "help@example.io", 1},
+		{"trailing words trimmed", "bob at example dot com dot Thanks", "bob@example.com", 0.65},
+		{"sentence end", "Reach Ann (at) example.com. Thanks!", "Ann@example.com", confidenceBracketedAt},
+		{"spanish words", "ventas arroba empresa punto mx", "ventas@empresa.mx", 0.65},
+		{"french words", "jean.dupont chez exemple point fr", "jean.dupont@exemple.fr", 0.65},
+		{"german dot", "info (at) beispiel punkt de", "info@beispiel.de", 1},
+		{"klammeraffe", "kontakt [Klammeraffe] firma [punkt] de", "kontakt@firma.de", 1},
+		{"polish", "jan [małpa] firma [kropka] pl", "jan@firma.pl", 1},
+		{"russian", "ivan собака mail точка ru", "ivan@mail.ru", 0.65},
+		{"fullwidth at and dot", "info＠example．com", "info@example.com", 1},
+		{"small commercial at", "info﹫example.com", "info@example.com", confidenceSymbolAt},
+		{"fullwidth brackets", "sales（at）example.com", "sales@example.com", confidenceBracketedAt},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			found := defaultDeobfuscator.reconstruct(testCase.text)
+			if len(found) != 1 {
+				t.Fatalf("reconstruct(%q) = %+v, want one address", testCase.text, found)
+			}
+
+			if found[0].email != testCase.wantEmail {
+				t.Errorf("reconstruct(%q) = %q, want %q", testCase.text, found[0].email, testCase.wantEmail)
+			}
+
+			if diff := found[0].confidence - testCase.wantConfidence; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("reconstruct(%q) confidence = %v, want %v", testCase.text, found[0].confidence, testCase.wantConfidence)
+			}
+
+			if snippet := testCase.text[found[0].start:found[0].end]; snippet == "" {
+				t.Errorf("reconstruct(%q) has an empty span", testCase.text)
+			}
+		})
+	}
+}
+
+func TestDeobfuscatorRejects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"plain address", "contact info@example.com"},
+		{"at in prose", "We met at the office at 10.30 today"},
+		{"stopword local part", "Contact us at example.com"},
+		{"at inside words", "data@rest and that.com"},
+		{"TLD named at", "see www.example.at for details"},
+		{"unknown TLD", "user [at] server.local"},
+		{"no domain", "user [at] localhost"},
+		{"unspaced word", "userATdomain.com"},
+		{"read more", "Read more at nytimes.com"},
+		{"products", "Buy our products at amazon.com"},
+		{"careers", "Careers at google.com"},
+		{"team", "Our team at acme.com"},
+		{"time and place", "meet at 5 pm at cafe.com"},
+		{"french prose", "Livraison gratuite chez amazon.fr"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if found := defaultDeobfuscator.reconstruct(testCase.text); len(found) != 0 {
+				t.Errorf("reconstruct(%q) = %+v, want nothing", testCase.text, found)
+			}
+		})
+	}
+}
+
+func TestDeobfuscatorCustomVocabulary(t *testing.T) {
+	t.Parallel()
+
+	text := "ali et firma nokta com"
+
+	if found := defaultDeobfuscator.reconstruct(text); len(found) != 0 {
+		t.Fatalf("default vocabularies reconstruct(%q) = %+v, want nothing", text, found)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ExtraSeparatorVocabularies = append(cfg.ExtraSeparatorVocabularies,
+		SeparatorVocabulary{Language: "tr", At: []string{"ET"}, Dot: []string{"nokta"}})
+
+	emailSet := newEmails(newExtractor(cfg), nil, nil)
+	emailSet.parseEmails([]byte(text), testOrigin())
+
+	if got := emailSet.toSlice(); len(got) != 1 || got[0] != "ali@firma.com" {
+		t.Errorf("parseEmails(%q) = %v, want [ali@firma.com]", text, got)
+	}
+
+	// Extra vocabularies extend the built-in ones
+	builtIn := "ventas arroba empresa punto mx"
+	emailSet.parseEmails([]byte(builtIn), testOrigin())
+
+	if got := emailSet.toSlice(); !slices.Contains(got, "ventas@empresa.mx") {
+		t.Errorf("parseEmails(%q) with an extra vocabulary = %v, want ventas@empresa.mx", builtIn, got)
+	}
+}
+
+func TestDeobfuscatorZeroConfig(t *testing.T) {
+	t.Parallel()
+
+	text := "user[at]acme.com or jane AT acme DOT org"
+
+	emailSet := newEmails(newExtractor(Config{}), nil, nil) //nolint:exhaustruct // the zero config must work
+	emailSet.parseEmails([]byte(text), testOrigin())
+
+	got := emailSet.toSlice()
+	slices.Sort(got)
+
+	if want := []string{"jane@acme.org", "user@acme.com"}; !slices.Equal(got, want) {
+		t.Errorf("parseEmails(%q) with a zero config = %v, want %v", text, got, want)
+	}
+}
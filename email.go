@@ -2,12 +2,13 @@ package emailscraper
 
 import (
 	"bytes"
+	"log"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
-
-	"github.com/lawzava/go-tld"
+	"time"
 )
 
 const (
@@ -17,26 +18,256 @@ const (
 	minTLDLength = 2
 	// minCloudflareEmailLength is the minimum length for a valid Cloudflare-encoded email (2 hex chars for XOR key).
 	minCloudflareEmailLength = 2
+	// snippetRadius is the number of bytes kept on each side of a match in a finding snippet.
+	snippetRadius = 40
 )
 
+// origin identifies the page a body was extracted from.
+type origin struct {
+	url   string
+	depth int
+}
+
+// sighting is a single observation of an email address.
+type sighting struct {
+	origin
+
+	method  Method
+	snippet string
+	// confidence is how certain the extraction is, from 0 to 1.
+	confidence float64
+	// displayName and context are only known for some methods, such as the link text and subject of mailto links.
+	displayName string
+	context     string
+}
+
+// extractor holds the configurable parts of email extraction; it is shared by all sessions of a scraper.
+type extractor struct {
+	deobfuscator *deobfuscator
+	domainForm   DomainForm
+	validation   ValidationMode
+	normalize    NormalizeConfig
+	// falsePositives drops matches that are written like addresses but are not.
+	falsePositives FalsePositiveConfig
+	// debug logs why matches were rejected.
+	debug bool
+}
+
+// newExtractor prepares email extraction as configured.
+func newExtractor(cfg Config) *extractor {
+	return &extractor{
+		deobfuscator:   newDeobfuscator(append(DefaultSeparatorVocabularies(), cfg.ExtraSeparatorVocabularies...)),
+		domainForm:     cfg.DomainForm,
+		validation:     cfg.Validation,
+		normalize:      cfg.Normalize,
+		falsePositives: cfg.FalsePositives,
+		debug:          cfg.Debug,
+	}
+}
+
+// prepare turns a match into the address it is recorded as and the key it is deduplicated by,
+// reporting false when it is not a valid address.
+func (x *extractor) prepare(email string) (string, string, bool) {
+	address, key, err := x.accept(email)
+	if err != nil {
+		if x.debug {
+			log.Println("rejected email", strconv.Quote(email)+":", err)
+		}
+
+		return "", "", false
+	}
+
+	return address, key, true
+}
+
+// accept validates, normalizes and classifies a match; the error tells why it was rejected.
+func (x *extractor) accept(email string) (string, string, error) {
+	err := validateAddress(email, x.validation)
+	if err != nil {
+		return "", "", err
+	}
+
+	address, key := normalizeAddress(email, x.normalize)
+
+	err = x.falsePositives.classify(address)
+	if err != nil {
+		return "", "", err
+	}
+
+	return convertDomain(address, x.domainForm), key, nil
+}
+
+// defaultExtractor is used by sets created without an extractor.
+//
+//nolint:gochecknoglobals // immutable after initialization
+var defaultExtractor = &extractor{
+	deobfuscator: defaultDeobfuscator,
+	domainForm:   DomainAsFound,
+	validation:   ValidationLenient,
+	normalize:    DefaultNormalizeConfig(),
+	// Sets without an extractor only tell whether a body has addresses at all, see containsEmails
+	falsePositives: FalsePositiveConfig{
+		Rules:                 nil,
+		AssetExtensions:       nil,
+		ChunkLabels:           nil,
+		PlaceholderDomains:    nil,
+		PlaceholderLocalParts: nil,
+		PlaceholderAddresses:  nil,
+	},
+	debug: false,
+}
+
 type emails struct {
-	set map[string]struct{}
+	set map[string]*Finding
 	m   sync.Mutex
+
+	// extractor configures extraction; nil uses defaultExtractor.
+	extractor *extractor
+
+	// verify, when set, verifies every newly accepted email before it is reported.
+	verify func(email string) Verification
+	// verifying tracks the verifications still running, see wait.
+	verifying sync.WaitGroup
+
+	// onFinding, when set, is called with every newly accepted email.
+	onFinding func(Finding)
+}
+
+// newEmails returns an empty set extracting as configured by ext (nil uses the defaults);
+// verify and onFinding (when not nil) are called with every newly accepted email.
+func newEmails(ext *extractor, verify func(string) Verification, onFinding func(Finding)) *emails {
+	return &emails{
+		set:       nil,
+		m:         sync.Mutex{},
+		extractor: ext,
+		verify:    verify,
+		verifying: sync.WaitGroup{},
+		onFinding: onFinding,
+	}
+}
+
+// extraction returns the extractor of the set.
+func (s *emails) extraction() *extractor {
+	if s.extractor == nil {
+		return defaultExtractor
+	}
+
+	return s.extractor
 }
 
-func (s *emails) add(email string) {
-	if !isValidEmail(email) {
+func (s *emails) add(spelling string, seen sighting) {
+	spelling = strings.TrimRight(spelling, trailingPunctuation)
+
+	email, key, ok := s.extraction().prepare(spelling)
+	if !ok {
 		return
 	}
 
 	s.m.Lock()
-	defer s.m.Unlock()
 
 	if s.set == nil {
-		s.set = make(map[string]struct{})
+		s.set = make(map[string]*Finding)
+	}
+
+	if finding, ok := s.set[key]; ok {
+		if seen.url != "" && !slices.Contains(finding.Sources, seen.url) {
+			finding.Sources = append(finding.Sources, seen.url)
+		}
+
+		if !slices.Contains(finding.Spellings, spelling) {
+			finding.Spellings = append(finding.Spellings, spelling)
+		}
+
+		// Domains kept as found prefer their Unicode spelling, whichever was seen first
+		if s.extraction().domainForm == DomainAsFound && email != finding.Email &&
+			convertDomain(email, DomainUnicode) == email {
+			finding.Email = email
+		}
+
+		finding.Confidence = max(finding.Confidence, seen.confidence)
+
+		// Later sightings can name an address that was first seen without a name
+		if finding.DisplayName == "" {
+			finding.DisplayName = seen.displayName
+		}
+
+		if finding.Context == "" {
+			finding.Context = seen.context
+		}
+
+		s.m.Unlock()
+
+		return
+	}
+
+	sources := make([]string, 0, 1)
+	if seen.url != "" {
+		sources = append(sources, seen.url)
 	}
 
-	s.set[email] = struct{}{}
+	finding := &Finding{
+		Email:        email,
+		Spellings:    []string{spelling},
+		Sources:      sources,
+		Depth:        seen.depth,
+		Method:       seen.method,
+		FirstSeen:    time.Now(),
+		Snippet:      seen.snippet,
+		Confidence:   seen.confidence,
+		DisplayName:  seen.displayName,
+		Context:      seen.context,
+		Verification: Verification{Domain: DomainUnverified, MailHosts: nil, Mailbox: MailboxUnverified},
+	}
+	s.set[key] = finding
+
+	verify, notify := s.verify, s.onFinding
+	found := finding.clone()
+
+	s.m.Unlock()
+
+	if verify == nil {
+		// Notify outside the lock so slow consumers do not block other pages from recording emails
+		if notify != nil {
+			notify(found)
+		}
+
+		return
+	}
+
+	// DNS lookups and SMTP conversations take seconds, so verification runs beside the crawl instead
+	// of holding up the page the email was found on; the finding is reported once it is verified.
+	s.verifying.Go(func() {
+		verification := verify(email)
+
+		s.m.Lock()
+		finding.Verification = verification
+		found = finding.clone()
+		s.m.Unlock()
+
+		if notify != nil {
+			notify(found)
+		}
+	})
+}
+
+// wait blocks until every email added so far is verified and reported.
+func (s *emails) wait() {
+	s.verifying.Wait()
+}
+
+// findings returns a copy of all findings ordered by discovery time.
+func (s *emails) findings() []Finding {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	result := make([]Finding, 0, len(s.set))
+	for _, finding := range s.set {
+		result = append(result, finding.clone())
+	}
+
+	sortFindings(result)
+
+	return result
 }
 
 func (s *emails) toSlice() []string {
@@ -44,8 +275,8 @@ func (s *emails) toSlice() []string {
 	defer s.m.Unlock()
 
 	result := make([]string, 0, len(s.set))
-	for email := range s.set {
-		result = append(result, email)
+	for _, finding := range s.set {
+		result = append(result, finding.Email)
 	}
 
 	return result
@@ -60,33 +291,122 @@ func (s *emails) reset() {
 
 // Initialize once.
 var (
-	reg = regexp.MustCompile(`([a-zA-Z0-9._-]+@([a-zA-Z0-9_-]+\.)+[a-zA-Z0-9_-]+)`)
+	// Letters, marks and digits of any script are allowed, for internationalized addresses (RFC 6531),
+	// and so are the symbols of RFC 5322 atext. "%" is left to the percent-decoding pass.
+	reg = regexp.MustCompile(`([\p{L}\p{M}\p{N}._+'!#$&*/=?^` + "`" + `{|}~-]+` +
+		`@([\p{L}\p{M}\p{N}_-]+\.)+[\p{L}\p{M}\p{N}_-]+)`)
 
-	// Matches common obfuscation patterns: [AT], (at), {AT}, " AT ", etc.
-	obfuscatedSeparators = regexp.MustCompile(`\s*[\[\(\{]?\s*[aA][tT]\s*[\]\)\}]?\s*`)
+	// Matches markup tags so snippets contain readable text only.
+	markupTags = regexp.MustCompile(`<[^>]*>`)
 )
 
 // Parse any *@*.* string and append to the slice.
-func (s *emails) parseEmails(body []byte) {
-	res := reg.FindAll(body, -1)
+func (s *emails) parseEmails(body []byte, from origin) {
+	s.parseEmailsAs(body, from, MethodRegex)
+}
+
+// parseEmailsAs parses a body produced by another extraction pass; plain matches are attributed to method.
+func (s *emails) parseEmailsAs(body []byte, from origin, method Method) {
+	s.parseMatches(body, from, method)
+
+	// Obfuscated separators and percent escapes may be written as character references too, e.g. &#91;at&#93;
+	if decoded, ok := decodeCharacterReferences(body); ok {
+		s.parseMatches(decoded, from, MethodHTMLEntity)
 
-	for _, r := range res {
-		s.add(string(r))
+		body = decoded
 	}
 
-	body = obfuscatedSeparators.ReplaceAll(body, []byte("@"))
+	if decoded, ok := decodePercentEncoding(body); ok {
+		s.parseMatches(decoded, from, MethodPercentEncoded)
+	}
+
+	s.parseDeobfuscated(body, from)
+}
 
-	res = reg.FindAll(body, -1)
-	for _, r := range res {
-		s.add(string(r))
+// parseDeobfuscated adds the addresses written with obfuscated separators such as [at] or " dot ".
+func (s *emails) parseDeobfuscated(body []byte, from origin) {
+	for _, rebuilt := range s.extraction().deobfuscator.reconstruct(string(body)) {
+		s.add(rebuilt.email, sighting{
+			origin:      from,
+			method:      MethodDeobfuscated,
+			snippet:     snippetAround(body, rebuilt.start, rebuilt.end),
+			confidence:  rebuilt.confidence,
+			displayName: "",
+			context:     "",
+		})
 	}
 }
 
-func (s *emails) parseCloudflareEmail(cloudflareEncodedEmail string) {
+// parseMatches adds every regex match in body, attributed to the given method.
+func (s *emails) parseMatches(body []byte, from origin, method Method) {
+	for _, loc := range reg.FindAllIndex(body, -1) {
+		// A match right after "%" starts inside a percent escape, the decoding pass finds the whole address
+		if loc[0] > 0 && body[loc[0]-1] == '%' {
+			continue
+		}
+
+		match := string(body[loc[0]:loc[1]])
+		start, end := trimToAddressScript(match)
+
+		start += urlLocalPartStart(match[start:end])
+
+		// Quotes, dots and markup before the local part join it to the surrounding text, e.g. 'info@acme.com'
+		start += len(match[start:end]) - len(strings.TrimLeft(match[start:end], ".'`*|"))
+		start, end = loc[0]+start, loc[0]+end
+
+		s.add(string(body[start:end]), sighting{
+			origin:      from,
+			method:      method,
+			snippet:     snippetAround(body, start, end),
+			confidence:  1,
+			displayName: "",
+			context:     "",
+		})
+	}
+}
+
+// urlLocalPartStart returns where the address starts in match when its local part is the end of a URL
+// path or query, e.g. info@acme.com in /contact?email=info@acme.com. Addresses themselves hardly ever
+// contain "/" or "?", so other atext symbols such as "=" in bill=ops@acme.org are kept.
+func urlLocalPartStart(match string) int {
+	local := match[:max(strings.LastIndexByte(match, '@'), 0)]
+	if !strings.ContainsAny(local, "/?") {
+		return 0
+	}
+
+	return strings.LastIndexAny(local, "/?&=") + 1
+}
+
+func (s *emails) parseCloudflareEmail(cloudflareEncodedEmail string, from origin, surrounding string) {
 	decodedEmail := decodeCloudflareEmail(cloudflareEncodedEmail)
 	email := reg.FindString(decodedEmail)
 
-	s.add(email)
+	s.add(email, sighting{
+		origin:      from,
+		method:      MethodCloudflare,
+		snippet:     collapseSnippet(surrounding, 2*snippetRadius),
+		confidence:  1,
+		displayName: "",
+		context:     "",
+	})
+}
+
+// snippetAround returns readable text surrounding body[start:end].
+func snippetAround(body []byte, start, end int) string {
+	from := max(start-snippetRadius, 0)
+	to := min(end+snippetRadius, len(body))
+
+	return collapseSnippet(markupTags.ReplaceAllString(string(body[from:to]), " "), to-from)
+}
+
+// collapseSnippet squeezes whitespace in text and truncates it to at most limit bytes.
+func collapseSnippet(text string, limit int) string {
+	text = strings.Join(strings.Fields(strings.ToValidUTF8(text, "")), " ")
+	if len(text) <= limit {
+		return text
+	}
+
+	return strings.ToValidUTF8(text[:limit], "")
 }
 
 func decodeCloudflareEmail(email string) string {
@@ -115,31 +435,3 @@ func decodeCloudflareEmail(email string) string {
 
 	return buffer.String()
 }
-
-// Check if email looks valid.
-func isValidEmail(email string) bool {
-	if email == "" {
-		return false
-	}
-
-	split := strings.Split(email, ".")
-
-	if len(split) < minEmailDomainParts {
-		return false
-	}
-
-	ending := split[len(split)-1]
-
-	if len(ending) < minTLDLength {
-		return false
-	}
-
-	// check if TLD name actually exists and is not some image ending
-	if !tld.IsValid(ending) {
-		return false
-	}
-
-	_, err := strconv.Atoi(ending)
-
-	return err != nil
-}
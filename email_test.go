@@ -29,6 +29,10 @@ func TestIsValidEmail(t *testing.T) {
 		{"valid with underscore", "user_name@example.com", true},
 		{"valid org TLD", "contact@example.org", true},
 		{"valid net TLD", "info@example.net", true},
+		{"valid IDN domain", "müller@bücher.de", true},
+		{"valid IDN TLD", "иван@пример.рф", true},
+		{"valid punycode TLD", "info@example.xn--p1ai", true},
+		{"valid uppercase TLD", "info@EXAMPLE.COM", true},
 	}
 
 	for _, testCase := range tests {
@@ -75,7 +79,7 @@ func TestParseEmails(t *testing.T) {
 		t.Parallel()
 
 		emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-		emailSet.parseEmails([]byte("Contact us at test@example.com for info"))
+		emailSet.parseEmails([]byte("Contact us at test@example.com for info"), testOrigin())
 
 		result := emailSet.toSlice()
 		if len(result) != 1 {
@@ -91,7 +95,7 @@ func TestParseEmails(t *testing.T) {
 		t.Parallel()
 
 		emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-		emailSet.parseEmails([]byte("Contact test@example.com or support@example.org"))
+		emailSet.parseEmails([]byte("Contact test@example.com or support@example.org"), testOrigin())
 
 		result := emailSet.toSlice()
 		if len(result) != 2 {
@@ -103,7 +107,7 @@ func TestParseEmails(t *testing.T) {
 		t.Parallel()
 
 		emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-		emailSet.parseEmails([]byte("Email: user[AT]domain.com"))
+		emailSet.parseEmails([]byte("Email: user[AT]domain.com"), testOrigin())
 
 		result := emailSet.toSlice()
 		if len(result) != 1 {
@@ -115,7 +119,7 @@ func TestParseEmails(t *testing.T) {
 		t.Parallel()
 
 		emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-		emailSet.parseEmails([]byte("Email: user(at)domain.com"))
+		emailSet.parseEmails([]byte("Email: user(at)domain.com"), testOrigin())
 
 		result := emailSet.toSlice()
 		if len(result) != 1 {
@@ -127,7 +131,7 @@ func TestParseEmails(t *testing.T) {
 		t.Parallel()
 
 		emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-		emailSet.parseEmails([]byte("Email: user AT domain.com"))
+		emailSet.parseEmails([]byte("Email: user AT domain.com"), testOrigin())
 
 		result := emailSet.toSlice()
 		if len(result) != 1 {
@@ -139,7 +143,7 @@ func TestParseEmails(t *testing.T) {
 		t.Parallel()
 
 		emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-		emailSet.parseEmails([]byte("test@example.com and test@example.com and test@example.com"))
+		emailSet.parseEmails([]byte("test@example.com and test@example.com and test@example.com"), testOrigin())
 
 		result := emailSet.toSlice()
 		if len(result) != 1 {
@@ -151,7 +155,7 @@ func TestParseEmails(t *testing.T) {
 		t.Parallel()
 
 		emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-		emailSet.parseEmails([]byte("This is just some regular text without any emails"))
+		emailSet.parseEmails([]byte("This is just some regular text without any emails"), testOrigin())
 
 		result := emailSet.toSlice()
 		if len(result) != 0 {
@@ -163,7 +167,7 @@ func TestParseEmails(t *testing.T) {
 		t.Parallel()
 
 		emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-		emailSet.parseEmails([]byte("image@file.png and real@example.com"))
+		emailSet.parseEmails([]byte("image@file.png and real@example.com"), testOrigin())
 
 		result := emailSet.toSlice()
 		if len(result) != 1 {
@@ -176,7 +180,7 @@ func TestEmailsReset(t *testing.T) {
 	t.Parallel()
 
 	emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-	emailSet.add("test@example.com")
+	emailSet.add("test@example.com", testSighting())
 
 	if len(emailSet.toSlice()) != 1 {
 		t.Errorf("expected 1 email before reset, got %d", len(emailSet.toSlice()))
@@ -198,7 +202,7 @@ func TestEmailsThreadSafety(t *testing.T) {
 
 	for range numGoroutines {
 		go func() {
-			emailSet.add("test@example.com")
+			emailSet.add("test@example.com", testSighting())
 
 			done <- true
 		}()
@@ -237,7 +241,7 @@ func TestEmailsThreadSafetyMultipleEmails(t *testing.T) {
 			go func(addr string) {
 				defer waitGroup.Done()
 
-				emailSet.add(addr)
+				emailSet.add(addr, testSighting())
 			}(email)
 		}
 	}
@@ -254,9 +258,9 @@ func TestEmailsToSlice(t *testing.T) {
 	t.Parallel()
 
 	emailSet := &emails{} //nolint:exhaustruct // zero value is valid
-	emailSet.add("a@example.com")
-	emailSet.add("b@example.com")
-	emailSet.add("c@example.com")
+	emailSet.add("a@example.com", testSighting())
+	emailSet.add("b@example.com", testSighting())
+	emailSet.add("c@example.com", testSighting())
 
 	result := emailSet.toSlice()
 	if len(result) != 3 {
@@ -275,3 +279,60 @@ func TestEmailsToSlice(t *testing.T) {
 		}
 	}
 }
+
+//nolint:cyclop // test function with multiple assertions
+func TestEmailsFindings(t *testing.T) {
+	t.Parallel()
+
+	emailSet := &emails{} //nolint:exhaustruct // zero value is valid
+	emailSet.parseEmails([]byte("<p>Write to <b>sales@example.com</b> today</p>"),
+		origin{url: "https://example.com/", depth: 1})
+	emailSet.parseEmails([]byte("Support: help[at]example.com"), origin{url: "https://example.com/help", depth: 2})
+	emailSet.parseEmails([]byte("Again sales@example.com"), origin{url: "https://example.com/about", depth: 2})
+
+	findings := emailSet.findings()
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(findings))
+	}
+
+	sales, help := findings[0], findings[1]
+
+	if sales.Email != "sales@example.com" || sales.Method != MethodRegex || sales.Depth != 1 {
+		t.Errorf("unexpected first finding: %+v", sales)
+	}
+
+	if len(sales.Sources) != 2 || sales.Sources[1] != "https://example.com/about" {
+		t.Errorf("expected both source pages, got %v", sales.Sources)
+	}
+
+	if sales.Snippet != "Write to sales@example.com today" {
+		t.Errorf("unexpected snippet %q", sales.Snippet)
+	}
+
+	if help.Email != "help@example.com" || help.Method != MethodDeobfuscated || help.Depth != 2 {
+		t.Errorf("unexpected second finding: %+v", help)
+	}
+
+	if sales.Confidence != 1 || help.Confidence != confidenceBracketedAt {
+		t.Errorf("unexpected confidences %v and %v", sales.Confidence, help.Confidence)
+	}
+
+	if sales.FirstSeen.After(help.FirstSeen) {
+		t.Errorf("findings not ordered by discovery time")
+	}
+}
+
+func testOrigin() origin {
+	return origin{url: "https://example.com/", depth: 0}
+}
+
+func testSighting() sighting {
+	return sighting{
+		origin:      testOrigin(),
+		method:      MethodRegex,
+		snippet:     "",
+		confidence:  1,
+		displayName: "",
+		context:     "",
+	}
+}
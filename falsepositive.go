@@ -0,0 +1,195 @@
+package emailscraper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// minHashLength is the length from which a hexadecimal local part is taken for a hash.
+	minHashLength = 16
+	// minTokenLength is the length from which an alphanumeric local part dense with digits is taken
+	// for a generated token.
+	minTokenLength = 24
+	// tokenDigitRatio is the share of digits from which a long local part is taken for a generated token.
+	tokenDigitRatio = 0.25
+	// minHashLabelLength is the length from which a hexadecimal domain label is taken for a content hash.
+	minHashLabelLength = 8
+)
+
+// FalsePositiveRule names a class of strings that look like addresses but are not.
+type FalsePositiveRule string
+
+const (
+	// FalsePositiveAsset drops asset file names such as logo@2x.png or sprite@2x.svg.gz. Domains such as
+	// 3x.com are hosts: a resolution descriptor only counts when an asset extension follows it.
+	FalsePositiveAsset FalsePositiveRule = "asset"
+	// FalsePositiveVersion drops versioned package names such as package@1.2.3 or lib@v2.0.1-beta.io.
+	FalsePositiveVersion FalsePositiveRule = "version"
+	// FalsePositiveHash drops hash-like local parts, such as the keys of error reporting DSNs.
+	FalsePositiveHash FalsePositiveRule = "hash"
+	// FalsePositiveChunk drops the chunk names of bundled JavaScript, such as main@3f2a1b9c.chunk.io. Only
+	// file name extensions and labels left of the registrable domain are checked, so min.io stays an address.
+	FalsePositiveChunk FalsePositiveRule = "chunk"
+	// FalsePositivePlaceholder drops placeholder addresses such as yourname@domain.com or info@example.com.
+	FalsePositivePlaceholder FalsePositiveRule = "placeholder"
+)
+
+var errFalsePositive = errors.New("false positive")
+
+var (
+	// Matches the resolution descriptors of retina images at the start of a domain, e.g. 2x in logo@2x.png.
+	resolutionDescriptor = regexp.MustCompile(`^\d+(\.\d+)?x([._-]|$)`)
+
+	// Matches version numbers at the start of a domain, e.g. 1.2.3 in package@1.2.3.
+	versionNumber = regexp.MustCompile(`^[v^~=]?\d+\.\d+([.-]|$)`)
+
+	// Matches hexadecimal strings.
+	hexadecimal = regexp.MustCompile(`^[0-9a-f]+$`)
+)
+
+// FalsePositiveConfig configures which matches are dropped because they are not addresses, although
+// they are written like one.
+type FalsePositiveConfig struct {
+	// Rules are the classes of false positives dropped; an empty list keeps every valid address.
+	Rules []FalsePositiveRule
+	// AssetExtensions are the file extensions of assets, e.g. "png": matches ending in one are file names.
+	AssetExtensions []string
+	// ChunkLabels are domain labels that mark the file names of bundles, e.g. "chunk". They only count
+	// before a file extension or left of the registrable domain.
+	ChunkLabels []string
+	// PlaceholderDomains are domains used in examples; their subdomains match too.
+	PlaceholderDomains []string
+	// PlaceholderLocalParts are local parts used in examples, whatever the domain.
+	PlaceholderLocalParts []string
+	// PlaceholderAddresses are complete addresses used in examples.
+	PlaceholderAddresses []string
+}
+
+// DefaultFalsePositiveConfig returns every rule with the built-in lists of asset extensions and
+// placeholders. Append to the lists, or drop rules that remove addresses you need.
+func DefaultFalsePositiveConfig() FalsePositiveConfig {
+	return FalsePositiveConfig{
+		Rules: []FalsePositiveRule{
+			FalsePositiveAsset, FalsePositiveVersion, FalsePositiveHash, FalsePositiveChunk, FalsePositivePlaceholder,
+		},
+		AssetExtensions: []string{
+			"png", "jpg", "jpeg", "gif", "webp", "avif", "svg", "ico", "bmp", "tif", "tiff",
+			"mp4", "webm", "mp3", "wav", "woff", "woff2", "ttf", "otf", "eot",
+			"css", "scss", "js", "mjs", "cjs", "ts", "map", "json",
+		},
+		ChunkLabels: []string{"chunk", "bundle", "min", "module", "worker"},
+		PlaceholderDomains: []string{
+			"example.com", "example.org", "example.net", "example.edu",
+			"domain.com", "yourdomain.com", "your-domain.com", "mydomain.com", "yoursite.com", "yourcompany.com",
+		},
+		PlaceholderLocalParts: []string{
+			"yourname", "your.name", "your_name", "youremail", "your.email", "your-email", "yourmail",
+			"your.address", "firstname.lastname", "first.last", "name.surname", "vorname.nachname", "prenom.nom",
+			"someone", "somebody",
+		},
+		PlaceholderAddresses: []string{
+			"email@email.com", "name@email.com", "user@email.com", "mail@mail.com", "test@test.com",
+			"email@address.com",
+		},
+	}
+}
+
+// classify returns an error naming the first rule that takes email for a false positive.
+func (cfg FalsePositiveConfig) classify(email string) error {
+	at := strings.LastIndexByte(email, '@')
+	local, domain := strings.ToLower(email[:at]), strings.ToLower(email[at+1:])
+
+	for _, rule := range cfg.Rules {
+		if cfg.matches(rule, local, domain) {
+			return fmt.Errorf("%w: %s", errFalsePositive, rule)
+		}
+	}
+
+	return nil
+}
+
+// matches reports whether rule takes the lowercased local part and domain for a false positive.
+func (cfg FalsePositiveConfig) matches(rule FalsePositiveRule, local, domain string) bool {
+	labels := strings.Split(domain, ".")
+
+	switch rule {
+	case FalsePositiveAsset:
+		return slices.Contains(cfg.AssetExtensions, labels[len(labels)-1]) ||
+			(resolutionDescriptor.MatchString(domain) && slices.ContainsFunc(labels[1:], func(label string) bool {
+				return slices.Contains(cfg.AssetExtensions, label)
+			}))
+	case FalsePositiveVersion:
+		return versionNumber.MatchString(domain)
+	case FalsePositiveHash:
+		return hashLike(local)
+	case FalsePositiveChunk:
+		return slices.ContainsFunc(cfg.bundleLabels(domain, labels), func(label string) bool {
+			return slices.Contains(cfg.ChunkLabels, label) || contentHash(label)
+		})
+	case FalsePositivePlaceholder:
+		return slices.Contains(cfg.PlaceholderAddresses, local+"@"+domain) ||
+			slices.Contains(cfg.PlaceholderLocalParts, local) ||
+			slices.ContainsFunc(cfg.PlaceholderDomains, func(placeholder string) bool {
+				return domain == placeholder || strings.HasSuffix(domain, "."+placeholder)
+			})
+	default:
+		return false
+	}
+}
+
+// bundleLabels returns the labels of domain that may name a bundle rather than a host: those before
+// the extension of file names such as main.3f2a1b9c.js, else those left of the registrable domain, e.g.
+// 3f2a1b9c in main@3f2a1b9c.chunk.io. Registrable domains such as min.io or bundle.app are hosts.
+func (cfg FalsePositiveConfig) bundleLabels(domain string, labels []string) []string {
+	if slices.Contains(cfg.AssetExtensions, labels[len(labels)-1]) {
+		return labels[:len(labels)-1]
+	}
+
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		ascii = domain
+	}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(ascii)
+	if err != nil {
+		return nil
+	}
+
+	return labels[:max(len(labels)-strings.Count(registrable, ".")-1, 0)]
+}
+
+// hashLike reports whether a local part looks generated rather than chosen by a person: a long
+// hexadecimal string, e.g. a key or UUID, or a long token dense with digits.
+func hashLike(local string) bool {
+	compact := strings.ReplaceAll(local, "-", "")
+	if len(compact) >= minHashLength && hexadecimal.MatchString(compact) && strings.ContainsAny(compact, "0123456789") {
+		return true
+	}
+
+	if len(local) < minTokenLength {
+		return false
+	}
+
+	digits := 0
+
+	for _, char := range local {
+		if char >= '0' && char <= '9' {
+			digits++
+		}
+	}
+
+	return float64(digits) >= tokenDigitRatio*float64(len(local))
+}
+
+// contentHash reports whether a domain label is the content hash bundlers put into file names.
+func contentHash(label string) bool {
+	return len(label) >= minHashLabelLength && hexadecimal.MatchString(label) &&
+		strings.ContainsAny(label, "0123456789") && strings.ContainsAny(label, "abcdef")
+}
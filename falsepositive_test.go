@@ -0,0 +1,100 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFalsePositiveClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		email    string
+		expected FalsePositiveRule
+	}{
+		{"retina image", "logo@2x.png", FalsePositiveAsset},
+		{"compressed retina image", "sprite@2x.svg.gz", FalsePositiveAsset},
+		{"fractional descriptor", "icon@1.5x.webp", FalsePositiveAsset},
+		{"asset extension", "hero@banner.webp", FalsePositiveAsset},
+		{"semantic version", "package@1.2.3", FalsePositiveVersion},
+		{"prefixed version", "lib@v2.0.1-beta.io", FalsePositiveVersion},
+		{"sentry key", "9f86d081884c7d659a2feaa0c55ad015@o123.ingest.sentry.io", FalsePositiveHash},
+		{"uuid", "3f2504e0-4f89-11d3-9a0c-0305e82c3301@example.io", FalsePositiveHash},
+		{"webpack vendors chunk", "vendors~main@3f2a1b9c.chunk.io", FalsePositiveChunk},
+		{"bundle subdomain", "main@app.bundle.min.io", FalsePositiveChunk},
+		{"content hash subdomain", "runtime@3f2a1b9c.cdn.acme.co.uk", FalsePositiveChunk},
+		{"example domain", "info@example.com", FalsePositivePlaceholder},
+		{"example subdomain", "info@mail.example.org", FalsePositivePlaceholder},
+		{"placeholder name", "yourname@domain.com", FalsePositivePlaceholder},
+		{"placeholder local part", "Your.Email@acme.io", FalsePositivePlaceholder},
+		{"placeholder address", "email@email.com", FalsePositivePlaceholder},
+		{"real address", "jane.doe@acme.io", ""},
+		{"provider domain", "12345678@163.com", ""},
+		{"short hex", "cafe@acme.io", ""},
+		{"min domain", "team@min.io", ""},
+		{"bundle domain", "hello@bundle.app", ""},
+		{"module domain", "info@module.com", ""},
+		{"worker domain", "hello@worker.org", ""},
+		{"worker subdomain", "info@sub.worker.org", ""},
+		{"hex domain", "info@deadbeef42.com", ""},
+		{"tilde local part", "first~last@acme.io", ""},
+		{"mov domain", "x@y.mov", ""},
+		{"descriptor-like domain", "hi@3x.com", ""},
+		{"descriptor-like domain at a new TLD", "hello@10x.dev", ""},
+		{"descriptor-like domain at a long TLD", "team@2x.agency", ""},
+	}
+
+	cfg := DefaultFalsePositiveConfig()
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := cfg.classify(testCase.email)
+			want := "false positive: " + string(testCase.expected)
+
+			switch {
+			case testCase.expected == "" && err != nil:
+				t.Errorf("classify(%q) = %v, want no false positive", testCase.email, err)
+			case testCase.expected != "" && (!errors.Is(err, errFalsePositive) || err.Error() != want):
+				t.Errorf("classify(%q) = %v, want %s", testCase.email, err, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestFalsePositiveRulesAreConfigurable(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultFalsePositiveConfig()
+	cfg.Rules = []FalsePositiveRule{FalsePositivePlaceholder}
+	cfg.PlaceholderDomains = append(cfg.PlaceholderDomains, "acme.io")
+
+	if err := cfg.classify("logo@2x.png"); err != nil {
+		t.Errorf("classify() with the asset rule disabled = %v, want nil", err)
+	}
+
+	if err := cfg.classify("jane@sales.acme.io"); !errors.Is(err, errFalsePositive) {
+		t.Errorf("classify() of a custom placeholder domain = %v, want a false positive", err)
+	}
+}
+
+func TestFalsePositiveChunkFileNames(t *testing.T) {
+	t.Parallel()
+
+	// The asset rule drops these too, the chunk rule must do so on its own
+	cfg := DefaultFalsePositiveConfig()
+	cfg.Rules = []FalsePositiveRule{FalsePositiveChunk}
+
+	for _, email := range []string{"vendors~main@3f2a1b9c.chunk.js", "main@3f2a1b9c.js", "app@runtime.bundle.mjs"} {
+		if err := cfg.classify(email); !errors.Is(err, errFalsePositive) {
+			t.Errorf("classify(%q) = %v, want a false positive", email, err)
+		}
+	}
+
+	if err := cfg.classify("hello@styles.css"); err != nil {
+		t.Errorf("classify() of a file name without chunk labels = %v, want nil", err)
+	}
+}
@@ -0,0 +1,113 @@
+package emailscraper
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lawzava/go-tld"
+	"golang.org/x/net/idna"
+)
+
+// DomainForm selects how the domains of internationalized addresses are written in results.
+type DomainForm int
+
+const (
+	// DomainAsFound keeps domains as they are written on the page, e.g. bücher.de or xn--bcher-kva.de.
+	// An address seen in both spellings is reported with the Unicode one.
+	DomainAsFound DomainForm = iota
+	// DomainUnicode writes domains in Unicode, e.g. xn--bcher-kva.de becomes bücher.de.
+	DomainUnicode
+	// DomainASCII writes domains in their ASCII (punycode) form, e.g. bücher.de becomes xn--bcher-kva.de.
+	// Local parts are kept as they are, since they have no ASCII form.
+	DomainASCII
+)
+
+// convertDomain writes the domain of email in the given form. Domains that are not valid IDNA
+// host names are kept as they are.
+func convertDomain(email string, form DomainForm) string {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 || form == DomainAsFound {
+		return email
+	}
+
+	convert := idna.Lookup.ToASCII
+	if form == DomainUnicode {
+		convert = idna.Lookup.ToUnicode
+	}
+
+	domain, err := convert(email[at+1:])
+	if err != nil {
+		return email
+	}
+
+	return email[:at+1] + domain
+}
+
+// validTLD reports whether label is an existing top-level domain. Internationalized TLDs are listed
+// in their ASCII form, e.g. xn--p1ai for рф.
+func validTLD(label string) bool {
+	ascii, err := idna.Lookup.ToASCII(label)
+
+	return err == nil && tld.IsValid(ascii)
+}
+
+// spacelessScript reports whether r belongs to a script written without spaces between words, where
+// an address often abuts the surrounding text, e.g. 联系我们info@example.com.
+func spacelessScript(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai)
+}
+
+// scriptNeutral reports whether r joins the text on either side of it, such as separators and marks.
+func scriptNeutral(r rune) bool {
+	return strings.ContainsRune("._+-", r) || unicode.Is(unicode.M, r)
+}
+
+// trimToAddressScript narrows a match to the part written in the script of its separator's
+// neighbors, e.g. info@example.com in 联系我们info@example.com. It returns the offsets of the
+// narrowed match inside match.
+func trimToAddressScript(match string) (int, int) {
+	at := strings.LastIndexByte(match, '@')
+	if at < 0 {
+		return 0, len(match)
+	}
+
+	start := at
+	spaceless, known := false, false
+
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(match[:start])
+		if !scriptNeutral(r) {
+			if known && spacelessScript(r) != spaceless {
+				break
+			}
+
+			spaceless, known = spacelessScript(r), true
+		}
+
+		start -= size
+	}
+
+	end := at + 1
+	known = false
+
+	for end < len(match) {
+		r, size := utf8.DecodeRuneInString(match[end:])
+		if !scriptNeutral(r) {
+			if known && spacelessScript(r) != spaceless {
+				break
+			}
+
+			spaceless, known = spacelessScript(r), true
+		}
+
+		end += size
+	}
+
+	// The domain must not end with the separators that joined it to the trimmed text
+	for end > at+1 && strings.ContainsRune("._-", rune(match[end-1])) {
+		end--
+	}
+
+	return start, end
+}
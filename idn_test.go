@@ -0,0 +1,86 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestConvertDomain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		email    string
+		form     DomainForm
+		expected string
+	}{
+		{"as found", "müller@bücher.de", DomainAsFound, "müller@bücher.de"},
+		{"to ascii", "müller@bücher.de", DomainASCII, "müller@xn--bcher-kva.de"},
+		{"to unicode", "info@xn--bcher-kva.de", DomainUnicode, "info@bücher.de"},
+		{"ascii TLD", "иван@пример.рф", DomainASCII, "иван@xn--e1afmkfd.xn--p1ai"},
+		{"plain domain", "info@Example.com", DomainUnicode, "info@example.com"},
+		{"invalid domain kept", "info@exa_mple.com", DomainASCII, "info@exa_mple.com"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := convertDomain(testCase.email, testCase.form); got != testCase.expected {
+				t.Errorf("convertDomain(%q, %d) = %q, want %q", testCase.email, testCase.form, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestParseEmailsInternationalized(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"unicode local part and domain", "Kontakt: müller@bücher.de.", []string{"müller@bücher.de"}},
+		{"punycode domain", "info@xn--bcher-kva.de", []string{"info@xn--bcher-kva.de"}},
+		{"cyrillic", "Пишите: иван@пример.рф", []string{"иван@пример.рф"}},
+		{"han address", "邮箱：用户@例子.公司", []string{"用户@例子.公司"}},
+		{"abutting han text", "联系我们info@example.com获取帮助", []string{"info@example.com"}},
+		{"digits after han text", "邮箱123456@qq.com", []string{"123456@qq.com"}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			emailSet := &emails{} //nolint:exhaustruct // zero value is valid
+			emailSet.parseEmails([]byte(testCase.body), testOrigin())
+
+			got := emailSet.toSlice()
+			slices.Sort(got)
+
+			if !slices.Equal(got, testCase.expected) {
+				t.Errorf("parseEmails(%q) = %v, want %v", testCase.body, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestEmailsDomainForm(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.DomainForm = DomainASCII
+
+	emailSet := newEmails(newExtractor(cfg), nil, nil)
+	emailSet.parseEmails([]byte("müller@bücher.de and info@xn--bcher-kva.de"), testOrigin())
+
+	got := emailSet.toSlice()
+	slices.Sort(got)
+
+	expected := []string{"info@xn--bcher-kva.de", "müller@xn--bcher-kva.de"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("ASCII domains = %v, want %v", got, expected)
+	}
+}
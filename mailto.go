@@ -0,0 +1,113 @@
+package emailscraper
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+const (
+	// mailtoScheme is the URI scheme of email links.
+	mailtoScheme = "mailto:"
+	// maxDisplayNameLength is the number of bytes kept from the text of an email link.
+	maxDisplayNameLength = 100
+)
+
+// mailtoLink is a parsed mailto: URI.
+type mailtoLink struct {
+	recipients []*mail.Address
+	subject    string
+}
+
+// hasMailtoScheme reports whether href is a mailto: URI.
+func hasMailtoScheme(href string) bool {
+	href = strings.TrimSpace(href)
+
+	return len(href) >= len(mailtoScheme) && strings.EqualFold(href[:len(mailtoScheme)], mailtoScheme)
+}
+
+// parseMailtoURI parses a mailto: URI as described in RFC 6068: comma-separated recipients in the path
+// and in to, cc and bcc fields, and the subject. Recipients that are not valid addresses are dropped.
+func parseMailtoURI(href string) (mailtoLink, bool) {
+	link := mailtoLink{recipients: nil, subject: ""}
+
+	if !hasMailtoScheme(href) {
+		return link, false
+	}
+
+	to, query, _ := strings.Cut(strings.TrimSpace(href)[len(mailtoScheme):], "?")
+	link.addRecipients(to)
+
+	for field := range strings.SplitSeq(query, "&") {
+		name, value, _ := strings.Cut(field, "=")
+
+		switch strings.ToLower(percentDecode(name)) {
+		case "to", "cc", "bcc":
+			link.addRecipients(value)
+		case "subject":
+			link.subject = strings.TrimSpace(percentDecode(value))
+		}
+	}
+
+	return link, len(link.recipients) > 0
+}
+
+// addRecipients adds the addresses of a percent-encoded, comma-separated recipient list.
+func (l *mailtoLink) addRecipients(encoded string) {
+	list := strings.TrimSpace(percentDecode(encoded))
+	if list == "" {
+		return
+	}
+
+	// Parsing the whole list keeps commas inside quoted display names intact
+	addresses, err := mail.ParseAddressList(list)
+	if err == nil {
+		l.recipients = append(l.recipients, addresses...)
+
+		return
+	}
+
+	for recipient := range strings.SplitSeq(list, ",") {
+		address, err := mail.ParseAddress(strings.TrimSpace(recipient))
+		if err == nil {
+			l.recipients = append(l.recipients, address)
+		}
+	}
+}
+
+// percentDecode decodes percent-encoding; unlike query decoding, "+" is kept as it is valid in addresses.
+func percentDecode(text string) string {
+	decoded, err := url.PathUnescape(text)
+	if err != nil {
+		return text
+	}
+
+	return decoded
+}
+
+// parseMailto adds the recipients of a mailto: link. The link text is recorded as display name unless
+// it only repeats the address, and the subject as context.
+func (s *emails) parseMailto(href, text string, from origin, surrounding string) {
+	link, ok := parseMailtoURI(href)
+	if !ok {
+		return
+	}
+
+	linkText := collapseSnippet(text, maxDisplayNameLength)
+
+	for _, recipient := range link.recipients {
+		displayName := linkText
+		if displayName == "" || strings.Contains(strings.ToLower(displayName), strings.ToLower(recipient.Address)) {
+			displayName = recipient.Name
+		}
+
+		s.add(recipient.Address, sighting{
+			origin:      from,
+			method:      MethodMailto,
+			snippet:     collapseSnippet(surrounding, 2*snippetRadius),
+			confidence:  1,
+			displayName: displayName,
+			context:     link.subject,
+		})
+	}
+}
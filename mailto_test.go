@@ -0,0 +1,100 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseMailtoURI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		href           string
+		wantRecipients []string
+		wantSubject    string
+	}{
+		{"plain", "mailto:info@example.com", []string{"info@example.com"}, ""},
+		{"upper-case scheme", " MAILTO:info@example.com ", []string{"info@example.com"}, ""},
+		{"percent-encoded", "mailto:j%2Edoe%40example.com", []string{"j.doe@example.com"}, ""},
+		{"plus is kept", "mailto:sales+eu@example.com", []string{"sales+eu@example.com"}, ""},
+		{"unusual characters", "mailto:o'brien@example.ie", []string{"o'brien@example.ie"}, ""},
+		{
+			"comma-separated recipients",
+			"mailto:a@example.com,%20b@example.com",
+			[]string{"a@example.com", "b@example.com"},
+			"",
+		},
+		{
+			"to, cc and bcc fields",
+			"mailto:?to=a@example.com&CC=b@example.com,c@example.com&bcc=d%40example.com&body=hi",
+			[]string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"},
+			"",
+		},
+		{
+			"display name with comma",
+			`mailto:%22Doe,%20Jane%22%20%3Cjane@example.com%3E`,
+			[]string{"jane@example.com"},
+			"",
+		},
+		{
+			"subject",
+			"mailto:press@example.com?subject=Press%20inquiry%3A%20Q3",
+			[]string{"press@example.com"},
+			"Press inquiry: Q3",
+		},
+		{"invalid recipient dropped", "mailto:nobody,ok@example.com", []string{"ok@example.com"}, ""},
+		{"no recipients", "mailto:?subject=Hello", nil, "Hello"},
+		{"not a mailto link", "https://example.com/?to=a@example.com", nil, ""},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			link, ok := parseMailtoURI(testCase.href)
+			if ok != (len(testCase.wantRecipients) > 0) {
+				t.Errorf("parseMailtoURI(%q) ok = %v", testCase.href, ok)
+			}
+
+			recipients := make([]string, 0, len(link.recipients))
+			for _, recipient := range link.recipients {
+				recipients = append(recipients, recipient.Address)
+			}
+
+			if !slices.Equal(recipients, testCase.wantRecipients) {
+				t.Errorf("parseMailtoURI(%q) recipients = %v, want %v", testCase.href, recipients, testCase.wantRecipients)
+			}
+
+			if link.subject != testCase.wantSubject {
+				t.Errorf("parseMailtoURI(%q) subject = %q, want %q", testCase.href, link.subject, testCase.wantSubject)
+			}
+		})
+	}
+}
+
+func TestEmailsParseMailto(t *testing.T) {
+	t.Parallel()
+
+	emailSet := &emails{} //nolint:exhaustruct // zero value is valid
+	emailSet.parseMailto("mailto:jane@example.com?subject=Sales", "Jane Doe", testOrigin(), "Contact: Jane Doe")
+	emailSet.parseMailto("mailto:%22Support%22%20%3Chelp@example.com%3E", "help@example.com", testOrigin(), "")
+	emailSet.parseEmails([]byte("Again jane@example.com"), testOrigin())
+
+	findings := emailSet.findings()
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(findings))
+	}
+
+	jane, help := findings[0], findings[1]
+
+	if jane.Method != MethodMailto || jane.DisplayName != "Jane Doe" || jane.Context != "Sales" {
+		t.Errorf("unexpected first finding: %+v", jane)
+	}
+
+	// The link text only repeats the address, so the name from the URI is used
+	if help.DisplayName != "Support" {
+		t.Errorf("display name = %q, want %q", help.DisplayName, "Support")
+	}
+}
@@ -0,0 +1,97 @@
+package emailscraper
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// trailingPunctuation is the punctuation that ends sentences or encloses addresses and is stripped from
+// the end of matches, e.g. the dot of "Write to info@example.com.".
+const trailingPunctuation = ".,;:!?)]}>\"'"
+
+// NormalizeConfig configures how addresses are normalized before they are recorded. Every spelling that
+// normalizes to the same address is merged into one finding.
+type NormalizeConfig struct {
+	// LowercaseLocalPart lowercases local parts too. The standard leaves their case to the mail server,
+	// but hardly any server distinguishes Info@ from info@. Domains are always lowercased.
+	LowercaseLocalPart bool
+	// FoldAliases merges the aliases of providers known to deliver them to one mailbox, such as the
+	// plus-tags and dots of Gmail addresses: J.Doe+news@googlemail.com is recorded as jdoe@gmail.com.
+	FoldAliases bool
+}
+
+// DefaultNormalizeConfig returns the default normalization: local parts and aliases kept as written.
+func DefaultNormalizeConfig() NormalizeConfig {
+	return NormalizeConfig{
+		LowercaseLocalPart: false,
+		FoldAliases:        false,
+	}
+}
+
+// aliasProvider describes how a mail provider aliases its addresses.
+type aliasProvider struct {
+	// domain is the domain the provider's addresses are recorded under.
+	domain string
+	// ignoresDots reports whether the provider ignores dots in local parts.
+	ignoresDots bool
+}
+
+// aliasProviders are the providers that deliver plus-tagged local parts (jane+tag@) to the untagged
+// mailbox, by domain.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var aliasProviders = map[string]aliasProvider{
+	"gmail.com":      {domain: "gmail.com", ignoresDots: true},
+	"googlemail.com": {domain: "gmail.com", ignoresDots: true},
+	"outlook.com":    {domain: "outlook.com", ignoresDots: false},
+	"hotmail.com":    {domain: "hotmail.com", ignoresDots: false},
+	"live.com":       {domain: "live.com", ignoresDots: false},
+	"icloud.com":     {domain: "icloud.com", ignoresDots: false},
+	"me.com":         {domain: "me.com", ignoresDots: false},
+	"mac.com":        {domain: "mac.com", ignoresDots: false},
+	"fastmail.com":   {domain: "fastmail.com", ignoresDots: false},
+	"proton.me":      {domain: "proton.me", ignoresDots: false},
+	"protonmail.com": {domain: "protonmail.com", ignoresDots: false},
+	"pm.me":          {domain: "pm.me", ignoresDots: false},
+	"yandex.ru":      {domain: "yandex.ru", ignoresDots: false},
+	"yandex.com":     {domain: "yandex.com", ignoresDots: false},
+}
+
+// normalizeAddress returns the normalized form of a valid address and the key it is deduplicated by.
+// The key has the Unicode form of the domain, so that punycode and Unicode spellings of a domain merge;
+// the address keeps the domain as written, lowercased. Once merged, findings prefer the Unicode spelling.
+func normalizeAddress(email string, cfg NormalizeConfig) (string, string) {
+	at := strings.LastIndexByte(email, '@')
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+
+	if cfg.LowercaseLocalPart {
+		local = strings.ToLower(local)
+	}
+
+	unicodeDomain, err := idna.Lookup.ToUnicode(domain)
+	if err != nil {
+		unicodeDomain = domain
+	}
+
+	if cfg.FoldAliases {
+		if provider, ok := aliasProviders[unicodeDomain]; ok {
+			if folded := foldAlias(local, provider); folded != "" {
+				return folded + "@" + provider.domain, folded + "@" + provider.domain
+			}
+		}
+	}
+
+	return local + "@" + domain, local + "@" + unicodeDomain
+}
+
+// foldAlias returns the mailbox a provider delivers local to; aliasing providers ignore case.
+func foldAlias(local string, provider aliasProvider) string {
+	local, _, _ = strings.Cut(strings.ToLower(local), "+")
+
+	if provider.ignoresDots {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+
+	return local
+}
@@ -0,0 +1,119 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	t.Parallel()
+
+	asWritten := NormalizeConfig{LowercaseLocalPart: false, FoldAliases: false}
+	folding := NormalizeConfig{LowercaseLocalPart: false, FoldAliases: true}
+	lowercasing := NormalizeConfig{LowercaseLocalPart: true, FoldAliases: false}
+
+	tests := []struct {
+		name        string
+		email       string
+		cfg         NormalizeConfig
+		wantAddress string
+		wantKey     string
+	}{
+		{"lowercase", "Info@Example.COM", lowercasing, "info@example.com", "info@example.com"},
+		{"keep local case", "Info@Example.COM", DefaultNormalizeConfig(), "Info@example.com", "Info@example.com"},
+		{"punycode key", "info@XN--BCHER-KVA.de", asWritten, "info@xn--bcher-kva.de", "info@bücher.de"},
+		{"unicode domain", "info@BÜCHER.de", asWritten, "info@bücher.de", "info@bücher.de"},
+		{"aliases kept", "j.doe+news@gmail.com", DefaultNormalizeConfig(), "j.doe+news@gmail.com", "j.doe+news@gmail.com"},
+		{"gmail dots and tag", "J.Doe+news@googlemail.com", folding, "jdoe@gmail.com", "jdoe@gmail.com"},
+		{"plus tag only", "jane.doe+shop@outlook.com", folding, "jane.doe@outlook.com", "jane.doe@outlook.com"},
+		{"other provider", "jane+shop@example.com", folding, "jane+shop@example.com", "jane+shop@example.com"},
+		{"tag without mailbox", "+shop@gmail.com", folding, "+shop@gmail.com", "+shop@gmail.com"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			address, key := normalizeAddress(testCase.email, testCase.cfg)
+			if address != testCase.wantAddress || key != testCase.wantKey {
+				t.Errorf("normalizeAddress(%q) = %q, %q, want %q, %q",
+					testCase.email, address, key, testCase.wantAddress, testCase.wantKey)
+			}
+		})
+	}
+}
+
+func TestEmailsMergesSpellings(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.Normalize = NormalizeConfig{LowercaseLocalPart: true, FoldAliases: true}
+	cfg.FalsePositives.Rules = nil
+
+	emailSet := newEmails(newExtractor(cfg), nil, nil)
+	emailSet.add("Info@Example.com", testSighting())
+	emailSet.add("info@example.com.", testSighting())
+	emailSet.add("info@EXAMPLE.com", testSighting())
+	emailSet.add("j.doe+news@gmail.com", testSighting())
+	emailSet.add("jdoe@gmail.com", testSighting())
+
+	findings := emailSet.findings()
+	slices.SortFunc(findings, func(first, second Finding) int { return len(first.Email) - len(second.Email) })
+
+	if len(findings) != 2 {
+		t.Fatalf("findings = %+v, want 2 addresses", findings)
+	}
+
+	wantSpellings := [][]string{
+		{"j.doe+news@gmail.com", "jdoe@gmail.com"},
+		{"Info@Example.com", "info@example.com", "info@EXAMPLE.com"},
+	}
+	wantEmails := []string{"jdoe@gmail.com", "info@example.com"}
+
+	for i, finding := range findings {
+		if finding.Email != wantEmails[i] || !slices.Equal(finding.Spellings, wantSpellings[i]) {
+			t.Errorf("finding = %s %v, want %s %v", finding.Email, finding.Spellings, wantEmails[i], wantSpellings[i])
+		}
+	}
+}
+
+func TestEmailsKeepLocalCaseByDefault(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.FalsePositives.Rules = nil
+
+	emailSet := newEmails(newExtractor(cfg), nil, nil)
+	emailSet.add("Info@Example.com", testSighting())
+	emailSet.add("info@example.com", testSighting())
+
+	got := emailSet.toSlice()
+	slices.Sort(got)
+
+	if want := []string{"Info@example.com", "info@example.com"}; !slices.Equal(got, want) {
+		t.Errorf("emails = %v, want %v", got, want)
+	}
+}
+
+func TestEmailsPreferUnicodeDomains(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.FalsePositives.Rules = nil
+
+	emailSet := newEmails(newExtractor(cfg), nil, nil)
+	emailSet.add("info@xn--bcher-kva.de", testSighting())
+	emailSet.add("info@bücher.de", testSighting())
+	emailSet.add("info@XN--BCHER-KVA.de", testSighting())
+
+	findings := emailSet.findings()
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want one address", findings)
+	}
+
+	wantSpellings := []string{"info@xn--bcher-kva.de", "info@bücher.de", "info@XN--BCHER-KVA.de"}
+	if findings[0].Email != "info@bücher.de" || !slices.Equal(findings[0].Spellings, wantSpellings) {
+		t.Errorf("finding = %s %v, want info@bücher.de %v", findings[0].Email, findings[0].Spellings, wantSpellings)
+	}
+}
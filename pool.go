@@ -0,0 +1,395 @@
+package emailscraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// defaultMaxBrowsers is the default number of browser processes kept alive by the pool.
+	defaultMaxBrowsers = 1
+	// defaultMaxTabs is the default number of tabs rendering at once across all browsers.
+	defaultMaxTabs = 4
+	// defaultBrowserIdleTimeout is the default time an unused browser is kept alive.
+	defaultBrowserIdleTimeout = time.Minute
+	// defaultBrowserRecycleAfter is the default number of tabs a browser serves before it is restarted.
+	defaultBrowserRecycleAfter = 100
+	// poolJanitorDivisor sets how often idle browsers are checked, as a fraction of the idle timeout.
+	poolJanitorDivisor = 2
+	// remoteTabCloseTimeout bounds closing the tab opened on a remote browser.
+	remoteTabCloseTimeout = time.Second
+)
+
+// ErrScraperClosed is returned when rendering is requested after Scraper.Close.
+var ErrScraperClosed = errors.New("scraper closed")
+
+// browserPool keeps headless browsers alive between renders and hands out tabs.
+type browserPool struct {
+	cfg     ChromeConfig
+	options []chromedp.ExecAllocatorOption
+
+	// tabs limits the number of tabs open at once across all browsers.
+	tabs chan struct{}
+
+	m        sync.Mutex
+	browsers []*pooledBrowser
+	// starting counts the browsers being started, which already take a place in the pool.
+	starting int
+	closed   bool
+
+	// janitor is set while the goroutine closing idle browsers runs. It stops once the pool is empty,
+	// so a Scraper that is never closed does not keep it running.
+	janitor bool
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// pooledBrowser is a single browser process owned by the pool.
+type pooledBrowser struct {
+	ctx         context.Context //nolint:containedctx // chromedp addresses browsers through contexts
+	cancel      context.CancelFunc
+	allocCancel context.CancelFunc
+	// remote is set for browsers attached to with RemoteURL, which the pool must not shut down.
+	remote bool
+
+	active   int
+	served   int
+	lastUsed time.Time
+}
+
+func newBrowserPool(cfg ChromeConfig) *browserPool {
+	maxTabs := cfg.MaxTabs
+	if maxTabs <= 0 {
+		maxTabs = defaultMaxTabs
+	}
+
+	if cfg.MaxBrowsers <= 0 {
+		cfg.MaxBrowsers = defaultMaxBrowsers
+	}
+
+	return &browserPool{
+		cfg:      cfg,
+		options:  chromeAllocatorOptions(cfg),
+		tabs:     make(chan struct{}, maxTabs),
+		m:        sync.Mutex{},
+		browsers: nil,
+		starting: 0,
+		closed:   false,
+		janitor:  false,
+		stop:     make(chan struct{}),
+		stopped:  sync.WaitGroup{},
+	}
+}
+
+// acquire opens a tab that is closed once ctx is done or release is called.
+func (p *browserPool) acquire(ctx context.Context) (context.Context, func(), error) {
+	select {
+	case p.tabs <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, fmt.Errorf("waiting for a browser tab: %w", ctx.Err())
+	}
+
+	browser, err := p.pick()
+	if err != nil {
+		<-p.tabs
+
+		return nil, nil, err
+	}
+
+	tabCtx, tabCancel := chromedp.NewContext(browser.ctx)
+
+	// The tab belongs to the long-lived browser, so close it as soon as the caller gives up.
+	stopAfter := context.AfterFunc(ctx, tabCancel)
+
+	release := func() {
+		stopAfter()
+		tabCancel()
+
+		p.done(browser)
+
+		<-p.tabs
+	}
+
+	return tabCtx, release, nil
+}
+
+// pick returns the least busy healthy browser, starting one when the pool has room. Browsers are
+// started without holding p.m, so tabs of running browsers are handed out meanwhile.
+func (p *browserPool) pick() (*pooledBrowser, error) {
+	p.m.Lock()
+
+	if p.closed {
+		p.m.Unlock()
+
+		return nil, ErrScraperClosed
+	}
+
+	p.startJanitor()
+
+	// Drop crashed browsers so they are replaced below
+	p.browsers = slices.DeleteFunc(p.browsers, func(browser *pooledBrowser) bool {
+		if browser.alive() {
+			return false
+		}
+
+		browser.close()
+
+		return true
+	})
+
+	picked := p.leastBusy()
+	if picked != nil && (picked.active == 0 || len(p.browsers)+p.starting >= p.cfg.MaxBrowsers) {
+		browser := p.use(picked)
+
+		p.m.Unlock()
+
+		return browser, nil
+	}
+
+	// Reserve the slot of the new browser so concurrent picks do not start more than MaxBrowsers
+	p.starting++
+
+	p.m.Unlock()
+
+	started, err := p.launch()
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.starting--
+
+	switch {
+	case err == nil && p.closed:
+		started.close()
+
+		return nil, ErrScraperClosed
+	case err == nil:
+		p.browsers = append(p.browsers, started)
+
+		return p.use(started), nil
+	case picked != nil && slices.Contains(p.browsers, picked) && !p.closed:
+		// The busy browser may have been retired or closed while the new one was starting
+		return p.use(picked), nil
+	default:
+		return nil, err
+	}
+}
+
+// leastBusy returns the browser with the fewest open tabs that is not retiring, or nil.
+// The caller must hold p.m.
+func (p *browserPool) leastBusy() *pooledBrowser {
+	var picked *pooledBrowser
+
+	for _, browser := range p.browsers {
+		if p.retiring(browser) {
+			continue
+		}
+
+		if picked == nil || browser.active < picked.active {
+			picked = browser
+		}
+	}
+
+	return picked
+}
+
+// use marks a tab of browser as in use. The caller must hold p.m.
+func (p *browserPool) use(browser *pooledBrowser) *pooledBrowser {
+	browser.active++
+	browser.served++
+	browser.lastUsed = time.Now()
+
+	return browser
+}
+
+// done marks a tab of browser as released and retires the browser once it has served enough tabs.
+func (p *browserPool) done(browser *pooledBrowser) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	browser.active--
+	browser.lastUsed = time.Now()
+
+	if browser.active == 0 && p.retiring(browser) {
+		p.remove(browser)
+	}
+}
+
+// retiring reports whether browser should not get new tabs because it is due for a restart.
+func (p *browserPool) retiring(browser *pooledBrowser) bool {
+	return p.cfg.RecycleAfter > 0 && browser.served >= p.cfg.RecycleAfter
+}
+
+// launch starts (or, with a remote URL, attaches to) a browser. The caller adds it to the pool.
+func (p *browserPool) launch() (*pooledBrowser, error) {
+	allocCtx, allocCancel := p.allocate()
+	browserCtx, cancel := chromedp.NewContext(allocCtx)
+
+	// Running without actions starts the browser process or opens the connection
+	err := chromedp.Run(browserCtx)
+	if err != nil {
+		cancel()
+		allocCancel()
+
+		return nil, fmt.Errorf("starting browser: %w", err)
+	}
+
+	browser := &pooledBrowser{
+		ctx:         browserCtx,
+		cancel:      cancel,
+		allocCancel: allocCancel,
+		remote:      p.cfg.RemoteURL != "",
+		active:      0,
+		served:      0,
+		lastUsed:    time.Now(),
+	}
+
+	return browser, nil
+}
+
+// allocate returns the allocator context for a new browser.
+func (p *browserPool) allocate() (context.Context, context.CancelFunc) {
+	// Browsers outlive the scrape that started them and are stopped by Close
+	if p.cfg.RemoteURL != "" {
+		return chromedp.NewRemoteAllocator(context.Background(), p.cfg.RemoteURL)
+	}
+
+	return chromedp.NewExecAllocator(context.Background(), p.options...)
+}
+
+// remove closes browser and drops it from the pool. The caller must hold p.m.
+func (p *browserPool) remove(browser *pooledBrowser) {
+	p.browsers = slices.DeleteFunc(p.browsers, func(candidate *pooledBrowser) bool {
+		return candidate == browser
+	})
+
+	browser.close()
+}
+
+// startJanitor starts periodically closing browsers that have been idle for too long, unless that
+// is already running. The caller must hold p.m.
+func (p *browserPool) startJanitor() {
+	idleTimeout := p.cfg.IdleTimeout
+	if idleTimeout <= 0 || p.janitor {
+		return
+	}
+
+	p.janitor = true
+
+	p.stopped.Go(func() {
+		ticker := time.NewTicker(idleTimeout / poolJanitorDivisor)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if p.closeIdle(idleTimeout) {
+					return
+				}
+			case <-p.stop:
+				return
+			}
+		}
+	})
+}
+
+// closeIdle closes browsers without open tabs that were last used before idleTimeout. It reports
+// whether the pool is left empty, in which case the janitor stops until the next browser is picked.
+func (p *browserPool) closeIdle(idleTimeout time.Duration) bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.browsers = slices.DeleteFunc(p.browsers, func(browser *pooledBrowser) bool {
+		if browser.active > 0 || (browser.alive() && time.Since(browser.lastUsed) < idleTimeout) {
+			return false
+		}
+
+		browser.close()
+
+		return true
+	})
+
+	if len(p.browsers) > 0 || p.starting > 0 {
+		return false
+	}
+
+	p.janitor = false
+
+	return true
+}
+
+// close shuts the pool down and stops every browser.
+func (p *browserPool) close() error {
+	p.m.Lock()
+
+	if p.closed {
+		p.m.Unlock()
+
+		return nil
+	}
+
+	p.closed = true
+	browsers := p.browsers
+	p.browsers = nil
+
+	p.m.Unlock()
+
+	close(p.stop)
+	p.stopped.Wait()
+
+	for _, browser := range browsers {
+		browser.close()
+	}
+
+	return nil
+}
+
+// alive reports whether the browser process is still reachable.
+func (b *pooledBrowser) alive() bool {
+	if b.ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case <-chromedp.FromContext(b.ctx).Browser.LostConnection:
+		return false
+	default:
+		return true
+	}
+}
+
+// close stops the browser process, waiting for it to exit. Remote browsers keep running, only the
+// tab opened on them and the connection to them are closed.
+func (b *pooledBrowser) close() {
+	if b.remote {
+		b.closeRemoteTab()
+	} else {
+		// Graceful shutdown waits for browser to close
+		_ = chromedp.Cancel(b.ctx)
+	}
+
+	b.cancel()
+	b.allocCancel()
+}
+
+// closeRemoteTab closes the tab opened when attaching to a remote browser. Browser.close, which
+// chromedp.Cancel may send, would shut down the browser for everyone else using it.
+func (b *pooledBrowser) closeRemoteTab() {
+	c := chromedp.FromContext(b.ctx)
+	if c == nil || c.Browser == nil || c.Target == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTabCloseTimeout)
+	defer cancel()
+
+	_ = target.CloseTarget(c.Target.TargetID).Do(cdp.WithExecutor(ctx, c.Browser))
+}
@@ -0,0 +1,130 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBrowserPoolClose(t *testing.T) {
+	t.Parallel()
+
+	pool := newBrowserPool(DefaultChromeConfig())
+
+	if err := pool.close(); err != nil {
+		t.Fatalf("close() error: %v", err)
+	}
+
+	// Closing twice is a no-op
+	if err := pool.close(); err != nil {
+		t.Fatalf("second close() error: %v", err)
+	}
+
+	_, _, err := pool.acquire(t.Context())
+	if !errors.Is(err, ErrScraperClosed) {
+		t.Errorf("acquire() after close error = %v, want %v", err, ErrScraperClosed)
+	}
+
+	if len(pool.tabs) != 0 {
+		t.Errorf("failed acquire kept %d tab slots", len(pool.tabs))
+	}
+}
+
+func TestBrowserPoolStartsBrowsersWithoutLock(t *testing.T) {
+	t.Parallel()
+
+	requested, unblock := make(chan struct{}), make(chan struct{})
+
+	// The DevTools endpoint hangs until the pool has been shown to be usable meanwhile
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(requested)
+		<-unblock
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := DefaultChromeConfig()
+	cfg.RemoteURL = server.URL
+	pool := newBrowserPool(cfg)
+
+	picked := make(chan error, 1)
+
+	go func() {
+		_, err := pool.pick()
+		picked <- err
+	}()
+
+	<-requested
+
+	closedIdle := make(chan struct{})
+
+	go func() {
+		pool.closeIdle(time.Hour)
+		close(closedIdle)
+	}()
+
+	select {
+	case <-closedIdle:
+	case <-time.After(time.Second):
+		t.Error("the pool stayed locked while a browser was starting")
+	}
+
+	close(unblock)
+
+	if err := <-picked; err == nil {
+		t.Error("pick() succeeded without a browser")
+	}
+
+	if err := pool.close(); err != nil {
+		t.Fatalf("close() error: %v", err)
+	}
+}
+
+func TestBrowserPoolStopsJanitorWhenEmpty(t *testing.T) {
+	t.Parallel()
+
+	// Browsers fail to start, so the pool stays empty after the pick
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := DefaultChromeConfig()
+	cfg.RemoteURL = server.URL
+	cfg.IdleTimeout = 10 * time.Millisecond
+	pool := newBrowserPool(cfg)
+
+	if _, err := pool.pick(); err == nil {
+		t.Fatal("pick() succeeded without a browser")
+	}
+
+	// The janitor returns without Close, which would otherwise wait for it
+	done := make(chan struct{})
+
+	go func() {
+		pool.stopped.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the janitor kept running for an empty pool")
+	}
+
+	pool.m.Lock()
+	running := pool.janitor
+	pool.m.Unlock()
+
+	if running {
+		t.Error("the janitor is still marked as running")
+	}
+
+	if err := pool.close(); err != nil {
+		t.Fatalf("close() error: %v", err)
+	}
+}
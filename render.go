@@ -0,0 +1,244 @@
+package emailscraper
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/gocolly/colly/v2"
+)
+
+const (
+	// minStaticTextLength is the amount of visible text below which a static page is considered script-driven.
+	minStaticTextLength = 200
+	// defaultScrollDelay is the default pause after each auto-scroll.
+	defaultScrollDelay = 500 * time.Millisecond
+	// defaultNetworkIdleTime is the default quiet period for the network idle wait.
+	defaultNetworkIdleTime = 500 * time.Millisecond
+	// networkIdlePollInterval is how often the network idle wait checks for in-flight requests.
+	networkIdlePollInterval = 50 * time.Millisecond
+)
+
+// RenderMode selects which pages are rendered in Chrome when EnableJavascript is set.
+// Only HTML documents are ever rendered; images, stylesheets, PDFs and JSON are parsed as downloaded.
+type RenderMode int
+
+const (
+	// RenderAlways renders every HTML page.
+	RenderAlways RenderMode = iota
+	// RenderScriptDriven renders a page only when its static HTML looks like it is built by JavaScript.
+	RenderScriptDriven
+	// RenderNoEmails renders a page only when no emails were found in its static HTML.
+	RenderNoEmails
+	// RenderHybrid renders a page when it looks script-driven or no emails were found in its static HTML.
+	RenderHybrid
+)
+
+var (
+	// Matches mount points of common single-page application frameworks.
+	spaRootMarkers = regexp.MustCompile(`(?i)<div[^>]+id=["']?(root|app|__next|__nuxt|svelte)["']?[^>]*>\s*</div>` +
+		`|<app-root|\sng-app\b|\sng-version=|data-reactroot|data-v-app|data-server-rendered`)
+
+	// Matches noscript blocks asking the visitor to enable JavaScript.
+	noscriptHints = regexp.MustCompile(`(?is)<noscript[^>]*>.*?(enable|requires?|turn on).{0,40}javascript.*?</noscript>`)
+
+	// Matches elements whose content is never shown as page text.
+	invisibleElements = regexp.MustCompile(
+		`(?is)<(script|style|noscript|template)\b[^>]*>.*?</(script|style|noscript|template)>`)
+)
+
+// shouldRender reports whether response has to be rendered in Chrome under mode.
+func shouldRender(mode RenderMode, response *colly.Response) bool {
+	if !isHTMLResponse(response) {
+		return false
+	}
+
+	switch mode {
+	case RenderScriptDriven:
+		return looksScriptDriven(response.Body)
+	case RenderNoEmails:
+		return !containsEmails(response.Body)
+	case RenderHybrid:
+		return looksScriptDriven(response.Body) || !containsEmails(response.Body)
+	case RenderAlways:
+		return true
+	default:
+		return true
+	}
+}
+
+// isHTMLResponse reports whether response is an HTML document, sniffing the body when no type is declared.
+func isHTMLResponse(response *colly.Response) bool {
+	contentType := ""
+	if response.Headers != nil {
+		contentType = response.Headers.Get("Content-Type")
+	}
+
+	if contentType == "" {
+		contentType = http.DetectContentType(response.Body)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// looksScriptDriven reports whether static HTML appears to get its content from JavaScript.
+func looksScriptDriven(body []byte) bool {
+	if spaRootMarkers.Match(body) || noscriptHints.Match(body) {
+		return true
+	}
+
+	text := invisibleElements.ReplaceAll(body, []byte(" "))
+	text = markupTags.ReplaceAll(text, []byte(" "))
+
+	return len(strings.Join(strings.Fields(string(text)), " ")) < minStaticTextLength
+}
+
+// containsEmails reports whether the static body yields at least one email.
+func containsEmails(body []byte) bool {
+	probe := newEmails(nil, nil, nil)
+	probe.parseEmails(body, origin{url: "", depth: 0})
+
+	return len(probe.toSlice()) > 0
+}
+
+// RenderStrategy controls what a rendered page is given time for before its DOM is captured.
+// The zero value captures the DOM as soon as the body is ready.
+type RenderStrategy struct {
+	// WaitSelector waits until an element matching this CSS selector is in the DOM.
+	WaitSelector string
+	// ScrollTimes scrolls to the bottom of the page this many times to trigger lazy loading.
+	ScrollTimes int
+	// ScrollDelay is the pause after each scroll; zero uses a short default.
+	ScrollDelay time.Duration
+	// WaitNetworkIdle waits until no request of the page has been in flight for NetworkIdleTime.
+	WaitNetworkIdle bool
+	// NetworkIdleTime is the quiet period WaitNetworkIdle waits for; zero uses a short default.
+	NetworkIdleTime time.Duration
+	// SettleDelay is a fixed pause right before the DOM is captured.
+	SettleDelay time.Duration
+}
+
+// RenderRule applies a strategy to the pages whose URL matches Pattern.
+type RenderRule struct {
+	Pattern  *regexp.Regexp
+	Strategy RenderStrategy
+}
+
+// strategyFor returns the strategy of the first rule matching url, falling back to the global one.
+func (cfg ChromeConfig) strategyFor(url string) RenderStrategy {
+	for _, rule := range cfg.Rules {
+		if rule.Pattern != nil && rule.Pattern.MatchString(url) {
+			return rule.Strategy
+		}
+	}
+
+	return cfg.Strategy
+}
+
+// waitActions returns the chromedp actions that wait as the strategy asks for.
+func (st RenderStrategy) waitActions(tracker *networkTracker) []chromedp.Action {
+	actions := make([]chromedp.Action, 0)
+
+	if st.WaitSelector != "" {
+		actions = append(actions, chromedp.WaitReady(st.WaitSelector, chromedp.ByQuery))
+	}
+
+	scrollDelay := st.ScrollDelay
+	if scrollDelay <= 0 {
+		scrollDelay = defaultScrollDelay
+	}
+
+	for range st.ScrollTimes {
+		actions = append(actions,
+			chromedp.Evaluate(`window.scrollTo(0, document.documentElement.scrollHeight)`, nil),
+			chromedp.Sleep(scrollDelay),
+		)
+	}
+
+	if st.WaitNetworkIdle {
+		idleTime := st.NetworkIdleTime
+		if idleTime <= 0 {
+			idleTime = defaultNetworkIdleTime
+		}
+
+		actions = append(actions, tracker.waitIdle(idleTime))
+	}
+
+	if st.SettleDelay > 0 {
+		actions = append(actions, chromedp.Sleep(st.SettleDelay))
+	}
+
+	return actions
+}
+
+// networkTracker follows the requests a page has in flight.
+type networkTracker struct {
+	m            sync.Mutex
+	inflight     map[network.RequestID]struct{}
+	lastActivity time.Time
+}
+
+func newNetworkTracker() *networkTracker {
+	return &networkTracker{
+		m:            sync.Mutex{},
+		inflight:     make(map[network.RequestID]struct{}),
+		lastActivity: time.Now(),
+	}
+}
+
+// listen records network events; it is registered with chromedp.ListenTarget.
+func (t *networkTracker) listen(event any) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	switch event := event.(type) {
+	case *network.EventRequestWillBeSent:
+		t.inflight[event.RequestID] = struct{}{}
+	case *network.EventLoadingFinished:
+		delete(t.inflight, event.RequestID)
+	case *network.EventLoadingFailed:
+		delete(t.inflight, event.RequestID)
+	default:
+		return
+	}
+
+	t.lastActivity = time.Now()
+}
+
+// idleFor reports whether nothing has been in flight for at least idleTime.
+func (t *networkTracker) idleFor(idleTime time.Duration) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	return len(t.inflight) == 0 && time.Since(t.lastActivity) >= idleTime
+}
+
+// waitIdle returns an action that blocks until the network has been idle for idleTime.
+func (t *networkTracker) waitIdle(idleTime time.Duration) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(networkIdlePollInterval)
+		defer ticker.Stop()
+
+		for !t.idleFor(idleTime) {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return fmt.Errorf("waiting for network idle: %w", ctx.Err())
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,219 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/gocolly/colly/v2"
+)
+
+func TestIsHTMLResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    bool
+	}{
+		{"html", "text/html; charset=utf-8", "", true},
+		{"xhtml", "application/xhtml+xml", "", true},
+		{"json", "application/json", `{"email":"a@example.com"}`, false},
+		{"image", "image/png", "\x89PNG\r\n\x1a\n", false},
+		{"pdf", "application/pdf", "%PDF-1.7", false},
+		{"css", "text/css", "body{}", false},
+		{"sniffed html", "", "<!DOCTYPE html><html><body></body></html>", true},
+		{"sniffed pdf", "", "%PDF-1.7", false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			response := testResponse(testCase.contentType, testCase.body)
+
+			if got := isHTMLResponse(response); got != testCase.expected {
+				t.Errorf("isHTMLResponse(%q) = %v, want %v", testCase.contentType, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestLooksScriptDriven(t *testing.T) {
+	t.Parallel()
+
+	article := "<p>" + strings.Repeat("Plenty of server rendered text. ", 20) + "</p>"
+
+	tests := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{"server rendered", "<html><body>" + article + "</body></html>", false},
+		{"empty body", "<html><body><script src=app.js></script></body></html>", true},
+		{"react root", `<html><body><div id="root"></div>` + article + "</body></html>", true},
+		{"angular", `<html><body><app-root></app-root>` + article + "</body></html>", true},
+		{"noscript hint", `<body><noscript>Please enable JavaScript.</noscript>` + article + "</body>", true},
+		{"text only in scripts", `<body><script>var t = "` + strings.Repeat("x", 500) + `";</script></body>`, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := looksScriptDriven([]byte(testCase.body)); got != testCase.expected {
+				t.Errorf("looksScriptDriven() = %v, want %v", got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestShouldRender(t *testing.T) {
+	t.Parallel()
+
+	withEmail := "<html><body><p>" + strings.Repeat("Text. ", 50) + "info@example.com</p></body></html>"
+	withoutEmail := "<html><body><p>" + strings.Repeat("Text. ", 50) + "</p></body></html>"
+	spa := `<html><body><div id="app"></div> info@example.com</body></html>`
+
+	tests := []struct {
+		name     string
+		mode     RenderMode
+		body     string
+		expected bool
+	}{
+		{"always", RenderAlways, withEmail, true},
+		{"script driven static page", RenderScriptDriven, withoutEmail, false},
+		{"script driven spa", RenderScriptDriven, spa, true},
+		{"no emails with email", RenderNoEmails, withEmail, false},
+		{"no emails without email", RenderNoEmails, withoutEmail, true},
+		{"hybrid static with email", RenderHybrid, withEmail, false},
+		{"hybrid static without email", RenderHybrid, withoutEmail, true},
+		{"hybrid spa with email", RenderHybrid, spa, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			response := testResponse("text/html", testCase.body)
+
+			if got := shouldRender(testCase.mode, response); got != testCase.expected {
+				t.Errorf("shouldRender() = %v, want %v", got, testCase.expected)
+			}
+		})
+	}
+
+	if shouldRender(RenderAlways, testResponse("application/json", `{}`)) {
+		t.Errorf("shouldRender() = true for a JSON response")
+	}
+}
+
+func testResponse(contentType, body string) *colly.Response {
+	headers := http.Header{}
+	if contentType != "" {
+		headers.Set("Content-Type", contentType)
+	}
+
+	//nolint:exhaustruct // only headers and body are inspected
+	return &colly.Response{
+		Headers: &headers,
+		Body:    []byte(body),
+	}
+}
+
+func TestStrategyFor(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultChromeConfig()
+	cfg.Strategy.SettleDelay = time.Second
+	cfg.Rules = []RenderRule{
+		{Pattern: regexp.MustCompile(`^https://shop\.example\.com/`), Strategy: RenderStrategy{ScrollTimes: 3}},
+		{Pattern: regexp.MustCompile(`example\.com/contact`), Strategy: RenderStrategy{WaitSelector: "#contact"}},
+		{Pattern: nil, Strategy: RenderStrategy{WaitNetworkIdle: true}},
+	}
+
+	tests := []struct {
+		url      string
+		expected RenderStrategy
+	}{
+		{"https://shop.example.com/contact", RenderStrategy{ScrollTimes: 3}},
+		{"https://www.example.com/contact", RenderStrategy{WaitSelector: "#contact"}},
+		{"https://www.example.com/about", RenderStrategy{SettleDelay: time.Second}},
+	}
+
+	for _, testCase := range tests {
+		if got := cfg.strategyFor(testCase.url); got != testCase.expected {
+			t.Errorf("strategyFor(%q) = %+v, want %+v", testCase.url, got, testCase.expected)
+		}
+	}
+}
+
+func TestRenderStrategyWaitActions(t *testing.T) {
+	t.Parallel()
+
+	tracker := newNetworkTracker()
+
+	tests := []struct {
+		name     string
+		strategy RenderStrategy
+		expected int
+	}{
+		{"zero value", RenderStrategy{}, 0},
+		{"selector", RenderStrategy{WaitSelector: ".email"}, 1},
+		{"scroll twice", RenderStrategy{ScrollTimes: 2}, 4},
+		{"everything", RenderStrategy{
+			WaitSelector: "main", ScrollTimes: 1, WaitNetworkIdle: true, SettleDelay: time.Millisecond,
+		}, 5},
+	}
+
+	for _, testCase := range tests {
+		if got := len(testCase.strategy.waitActions(tracker)); got != testCase.expected {
+			t.Errorf("%s: got %d actions, want %d", testCase.name, got, testCase.expected)
+		}
+	}
+}
+
+func TestNetworkTrackerIdle(t *testing.T) {
+	t.Parallel()
+
+	tracker := newNetworkTracker()
+	tracker.listen(&network.EventRequestWillBeSent{RequestID: "1"})
+	tracker.listen(&network.EventRequestWillBeSent{RequestID: "2"})
+	tracker.listen(&network.EventLoadingFinished{RequestID: "1"})
+
+	if tracker.idleFor(0) {
+		t.Fatalf("tracker idle while a request is in flight")
+	}
+
+	tracker.listen(&network.EventLoadingFailed{RequestID: "2"})
+
+	if !tracker.idleFor(0) {
+		t.Fatalf("tracker busy after all requests finished")
+	}
+
+	if tracker.idleFor(time.Hour) {
+		t.Fatalf("tracker idle for longer than it has been quiet")
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	if err := tracker.waitIdle(10 * time.Millisecond)(ctx); err != nil {
+		t.Fatalf("waitIdle() error: %v", err)
+	}
+
+	tracker.listen(&network.EventRequestWillBeSent{RequestID: "3"})
+
+	ctx, cancel = context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := tracker.waitIdle(10 * time.Millisecond)(ctx); err == nil {
+		t.Fatalf("waitIdle() returned while a request is in flight")
+	}
+}
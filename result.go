@@ -0,0 +1,157 @@
+package emailscraper
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Method identifies the extraction pass that produced a finding.
+type Method string
+
+const (
+	// MethodRegex marks emails matched as-is in a page body.
+	MethodRegex Method = "regex"
+	// MethodDeobfuscated marks emails reconstructed from obfuscated separators such as [at].
+	MethodDeobfuscated Method = "deobfuscated"
+	// MethodCloudflare marks emails decoded from Cloudflare data-cfemail attributes.
+	MethodCloudflare Method = "cloudflare"
+	// MethodHTMLEntity marks emails decoded from HTML character references such as &#64; or &commat;.
+	MethodHTMLEntity Method = "html-entity"
+	// MethodPercentEncoded marks emails decoded from percent-encoding such as %40.
+	MethodPercentEncoded Method = "percent-encoded"
+	// MethodMailto marks emails taken from mailto: links.
+	MethodMailto Method = "mailto"
+	// MethodScript marks emails written or returned by inline scripts run in the lightweight evaluator.
+	MethodScript Method = "script"
+)
+
+// Finding describes a single extracted email and where it was found.
+type Finding struct {
+	// Email is the normalized address, see NormalizeConfig.
+	Email string
+	// Spellings lists every spelling the address was written in, in discovery order, e.g. Info@Example.com
+	// and info@example.com.
+	Spellings []string
+	// Sources lists the URLs of every page the email was seen on, in discovery order.
+	Sources []string
+	// Depth is the crawl depth of the page the email was first seen on.
+	Depth int
+	// Method is the extraction pass that first produced the email.
+	Method Method
+	// FirstSeen is the time the email was first extracted.
+	FirstSeen time.Time
+	// Snippet is a short piece of text surrounding the first match.
+	Snippet string
+	// Confidence is how certain the extraction is, from 0 to 1. Only addresses reconstructed from
+	// obfuscated text score below 1, e.g. "jane at example dot com" scores lower than "jane[at]example.com".
+	Confidence float64
+	// DisplayName is the name shown for the address, such as the text of a mailto link.
+	DisplayName string
+	// Context is additional information about the address, such as the subject of a mailto link.
+	Context string
+	// Verification is the outcome of verifying the address, when VerifyDomains is set.
+	Verification Verification
+}
+
+// Result is the outcome of a detailed scrape.
+type Result struct {
+	// URL is the normalized starting URL of the scrape.
+	URL      string
+	Findings []Finding
+	Stats    Stats
+}
+
+// Stats summarizes a scrape.
+type Stats struct {
+	// PagesScraped is the number of responses parsed for emails.
+	PagesScraped int
+	// PagesRendered is the number of pages rendered in Chrome.
+	PagesRendered int
+	// FailedRequests is the number of failed request attempts, retries included.
+	FailedRequests int
+	// BlockedRequests is the number of requests rendered pages were not allowed to make, per resource type.
+	BlockedRequests map[string]int
+	// Duration is the wall-clock time the scrape took.
+	Duration time.Duration
+}
+
+// Emails returns the addresses of all findings.
+func (r Result) Emails() []string {
+	result := make([]string, 0, len(r.Findings))
+	for _, finding := range r.Findings {
+		result = append(result, finding.Email)
+	}
+
+	return result
+}
+
+// sortFindings orders findings by discovery time, then by address.
+func sortFindings(findings []Finding) {
+	slices.SortFunc(findings, func(first, second Finding) int {
+		if cmp := first.FirstSeen.Compare(second.FirstSeen); cmp != 0 {
+			return cmp
+		}
+
+		return strings.Compare(first.Email, second.Email)
+	})
+}
+
+// clone returns a copy of the finding that shares no slices with it.
+func (f *Finding) clone() Finding {
+	found := *f
+	found.Spellings = slices.Clone(f.Spellings)
+	found.Sources = slices.Clone(f.Sources)
+	found.Verification = f.Verification.clone()
+
+	return found
+}
+
+// crawlStats collects counters while a crawl is running.
+type crawlStats struct {
+	pages    atomic.Int64
+	rendered atomic.Int64
+	failed   atomic.Int64
+
+	m       sync.Mutex
+	blocked map[string]int
+}
+
+func newCrawlStats() *crawlStats {
+	//nolint:exhaustruct // atomic counters start at zero
+	return &crawlStats{
+		m:       sync.Mutex{},
+		blocked: make(map[string]int),
+	}
+}
+
+// addBlocked adds blocked request counts per resource type.
+func (c *crawlStats) addBlocked(blocked map[string]int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for resourceType, count := range blocked {
+		c.blocked[resourceType] += count
+	}
+}
+
+// snapshot returns the current counters as Stats.
+func (c *crawlStats) snapshot(duration time.Duration) Stats {
+	return Stats{
+		PagesScraped:    int(c.pages.Load()),
+		PagesRendered:   int(c.rendered.Load()),
+		FailedRequests:  int(c.failed.Load()),
+		BlockedRequests: c.blockedCounts(),
+		Duration:        duration,
+	}
+}
+
+func (c *crawlStats) blockedCounts() map[string]int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return maps.Clone(c.blocked)
+}
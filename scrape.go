@@ -2,9 +2,16 @@ package emailscraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 )
 
+// ErrNoPagesScraped is returned by ScrapeDetailed, ScrapeStream and batch scrapes when not a single page
+// of a site could be fetched and parsed. Scrape and ScrapeWithContext keep their original behavior and
+// report such a site as having no emails.
+var ErrNoPagesScraped = errors.New("no pages scraped")
+
 // Scrape is responsible for main scraping logic.
 func (s *Scraper) Scrape(url string) ([]string, error) {
 	return s.ScrapeWithContext(context.Background(), url)
@@ -12,51 +19,65 @@ func (s *Scraper) Scrape(url string) ([]string, error) {
 
 // ScrapeWithContext is responsible for main scraping logic with context support.
 func (s *Scraper) ScrapeWithContext(ctx context.Context, url string) ([]string, error) {
+	result, err := s.ScrapeDetailed(ctx, url)
+	if errors.Is(err, ErrNoPagesScraped) {
+		return result.Emails(), nil
+	}
+
+	return result.Emails(), err
+}
+
+// ScrapeDetailed scrapes like ScrapeWithContext, but reports where and how each email was found.
+func (s *Scraper) ScrapeDetailed(ctx context.Context, url string) (Result, error) {
+	return s.scrape(ctx, url, nil)
+}
+
+// scrape runs a crawl from url, calling onFinding (when not nil) for every newly found email.
+func (s *Scraper) scrape(ctx context.Context, url string, onFinding func(Finding)) (Result, error) {
 	url = getWebsite(url, true)
+	started := time.Now()
 
-	// Reset emails set for new scrape
-	s.emailsSet.reset()
+	sess, err := s.newSession(ctx, url, onFinding)
+	if err != nil {
+		var empty Result
 
-	if !s.cfg.FollowExternalLinks {
-		allowedDomains, err := prepareAllowedDomain(url)
-		if err != nil {
-			return nil, err
-		}
+		empty.URL = url
+
+		return empty, err
+	}
 
-		s.collector.AllowedDomains = allowedDomains
+	// Start the scrape
+	err = sess.visit(url)
+	if err != nil {
+		s.log("error while visiting secure domain: ", url, err.Error())
 	}
 
-	// Channel to signal completion
-	done := make(chan struct{})
+	sess.collector.Wait() // Wait for concurrent scrapes to finish
 
-	go func() {
-		// Start the scrape
-		err := s.collector.Visit(url)
+	if len(sess.emailsSet.toSlice()) == 0 && ctx.Err() == nil {
+		// Start the scrape on insecure url
+		err := sess.visit(getWebsite(url, false))
 		if err != nil {
-			s.log("error while visiting secure domain: ", url, err.Error())
+			s.log("error while visiting insecure domain: ", err.Error())
 		}
 
-		s.collector.Wait() // Wait for concurrent scrapes to finish
-
-		if len(s.emailsSet.toSlice()) == 0 {
-			// Start the scrape on insecure url
-			err := s.collector.Visit(getWebsite(url, false))
-			if err != nil {
-				s.log("error while visiting insecure domain: ", err.Error())
-			}
+		sess.collector.Wait() // Wait for concurrent scrapes to finish
+	}
 
-			s.collector.Wait() // Wait for concurrent scrapes to finish
-		}
+	// Emails found last may still be verified
+	sess.emailsSet.wait()
 
-		close(done)
-	}()
+	// Once canceled, pending requests, retries, renders and verifications stop on their own, so the
+	// waits above return promptly and nothing of this crawl keeps running after the call returns.
+	if ctx.Err() != nil {
+		return sess.result(url, started), fmt.Errorf("scraping canceled: %w", ctx.Err())
+	}
 
-	select {
-	case <-ctx.Done():
-		return s.emailsSet.toSlice(), fmt.Errorf("scraping canceled: %w", ctx.Err())
-	case <-done:
-		return s.emailsSet.toSlice(), nil
+	if sess.stats.pages.Load() == 0 {
+		return sess.result(url, started), fmt.Errorf("%w: %s", ErrNoPagesScraped, url)
 	}
+
+	return sess.result(url, started), nil
 }
 
 func getWebsite(url string, secure bool) string {
@@ -1,8 +1,17 @@
 package emailscraper_test
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/lawzava/emailscraper"
 )
@@ -39,3 +48,465 @@ func TestScrape(t *testing.T) {
 		})
 	}
 }
+
+func TestScrapeStream(t *testing.T) {
+	t.Parallel()
+
+	server := newTestSite(t, map[string]string{
+		"/":     `<a href="/team">Team</a> Contact: hello@example.com`,
+		"/team": `<p>Jane: jane@example.org</p>`,
+	})
+
+	stream := emailscraper.New(testConfig()).ScrapeStream(t.Context(), server.URL)
+
+	streamed := make([]string, 0)
+	for finding := range stream.Findings() {
+		streamed = append(streamed, finding.Email)
+	}
+
+	result, err := stream.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error: %v", err)
+	}
+
+	for _, email := range []string{"hello@example.com", "jane@example.org"} {
+		if !slices.Contains(streamed, email) {
+			t.Errorf("email %q not streamed, got: %v", email, streamed)
+		}
+	}
+
+	if len(result.Findings) != len(streamed) {
+		t.Errorf("result has %d findings, streamed %d", len(result.Findings), len(streamed))
+	}
+
+	if result.Stats.PagesScraped < 2 {
+		t.Errorf("expected at least 2 scraped pages, got %d", result.Stats.PagesScraped)
+	}
+}
+
+// testConfig returns a config suitable for crawling local test servers.
+func testConfig() emailscraper.Config {
+	cfg := emailscraper.DefaultConfig()
+	cfg.EnableJavascript = false
+	cfg.MaxRetries = 0
+	cfg.RateLimitDelay = time.Millisecond
+	// Test sites publish addresses at example domains
+	cfg.FalsePositives.Rules = slices.DeleteFunc(cfg.FalsePositives.Rules, func(rule emailscraper.FalsePositiveRule) bool {
+		return rule == emailscraper.FalsePositivePlaceholder
+	})
+
+	return cfg
+}
+
+// newTestSite serves the given path to HTML body pages.
+func newTestSite(t *testing.T, pages map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, ok := pages[request.URL.Path]
+		if !ok {
+			http.NotFound(writer, request)
+
+			return
+		}
+
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = writer.Write([]byte("<html><body>" + body + "</body></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestScrapeMany(t *testing.T) {
+	t.Parallel()
+
+	first := newTestSite(t, map[string]string{"/": `first@example.com`})
+	second := newTestSite(t, map[string]string{"/": `second@example.org`})
+
+	cfg := testConfig()
+	cfg.BatchConcurrency = 2
+
+	urls := []string{first.URL, "http://127.0.0.1:1", second.URL}
+
+	results := emailscraper.New(cfg).ScrapeMany(t.Context(), urls)
+	if len(results) != len(urls) {
+		t.Fatalf("expected %d results, got %d", len(urls), len(results))
+	}
+
+	for index, want := range []string{"first@example.com", "", "second@example.org"} {
+		result := results[index]
+
+		if result.URL != urls[index] {
+			t.Errorf("result %d is for %q, want %q", index, result.URL, urls[index])
+		}
+
+		if want == "" {
+			if !errors.Is(result.Err, emailscraper.ErrNoPagesScraped) {
+				t.Errorf("expected ErrNoPagesScraped for unreachable site, got %v", result.Err)
+			}
+
+			continue
+		}
+
+		if result.Err != nil {
+			t.Errorf("unexpected error for %q: %v", result.URL, result.Err)
+		}
+
+		if !slices.Contains(result.Result.Emails(), want) {
+			t.Errorf("email %q missing, got: %v", want, result.Result.Emails())
+		}
+	}
+}
+
+func TestScrapeManySitesDoNotShareRequestSlots(t *testing.T) {
+	t.Parallel()
+
+	const delay = 400 * time.Millisecond
+
+	urls := make([]string, 0, 4)
+
+	for range 4 {
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path == "/" {
+				time.Sleep(delay)
+			}
+
+			_, _ = writer.Write([]byte("<html><body>no emails</body></html>"))
+		}))
+		t.Cleanup(server.Close)
+
+		urls = append(urls, server.URL)
+	}
+
+	cfg := testConfig()
+	cfg.BatchConcurrency = len(urls)
+	cfg.Parallelism = 1
+
+	started := time.Now()
+	emailscraper.New(cfg).ScrapeMany(t.Context(), urls)
+
+	// Sharing request slots across sites would serialize the slow pages
+	if elapsed := time.Since(started); elapsed >= 2*delay {
+		t.Errorf("scraping %d sites took %v, want them scraped in parallel", len(urls), elapsed)
+	}
+}
+
+func TestScrapeDoesNotFollowOffSiteRedirects(t *testing.T) {
+	t.Parallel()
+
+	external := newTestSite(t, map[string]string{"/": `external@othercorp.com`})
+	externalURL := strings.Replace(external.URL, "127.0.0.1", "localhost", 1)
+
+	server := httptest.NewServer(http.RedirectHandler(externalURL, http.StatusFound))
+	t.Cleanup(server.Close)
+
+	scraper := emailscraper.New(testConfig())
+
+	// Scrape twice, so that a redirect check left over from an earlier session would show
+	for range 2 {
+		emails, err := scraper.ScrapeWithContext(t.Context(), server.URL)
+		if err != nil || len(emails) != 0 {
+			t.Errorf("ScrapeWithContext() = %v, %v, want the off-site redirect not followed", emails, err)
+		}
+	}
+}
+
+func TestScraperConcurrentScrapes(t *testing.T) {
+	t.Parallel()
+
+	sites := map[string]*httptest.Server{
+		"first@example.com":  newTestSite(t, map[string]string{"/": `<a href="/a">a</a> first@example.com`}),
+		"second@example.org": newTestSite(t, map[string]string{"/": `<a href="/a">a</a> second@example.org`}),
+	}
+
+	scraper := emailscraper.New(testConfig())
+
+	var waitGroup sync.WaitGroup
+
+	for email, site := range sites {
+		for range 2 {
+			waitGroup.Go(func() {
+				emails, err := scraper.ScrapeWithContext(t.Context(), site.URL)
+				if err != nil {
+					t.Errorf("ScrapeWithContext(%q) error: %v", site.URL, err)
+
+					return
+				}
+
+				if len(emails) != 1 || emails[0] != email {
+					t.Errorf("ScrapeWithContext(%q) = %v, want only %q", site.URL, emails, email)
+				}
+			})
+		}
+	}
+
+	waitGroup.Wait()
+}
+
+func TestScrapeCancellation(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := testConfig()
+	cfg.MaxRetries = 5
+	cfg.RetryDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+
+	_, err := emailscraper.New(cfg).ScrapeDetailed(ctx, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("scrape returned %v after cancellation", elapsed)
+	}
+
+	seen := requests.Load()
+
+	time.Sleep(100 * time.Millisecond)
+
+	if requests.Load() != seen {
+		t.Errorf("requests kept arriving after the scrape returned")
+	}
+}
+
+func TestScrapeUnreachableSiteHasNoEmails(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	emails, err := emailscraper.New(testConfig()).ScrapeWithContext(t.Context(), server.URL)
+	if err != nil || len(emails) != 0 {
+		t.Errorf("ScrapeWithContext() = %v, %v, want no emails and no error", emails, err)
+	}
+}
+
+func TestScrapeRetriesAreBounded(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := testConfig()
+	cfg.MaxRetries = 2
+	cfg.RetryDelay = time.Millisecond
+
+	result, err := emailscraper.New(cfg).ScrapeDetailed(t.Context(), server.URL)
+	if !errors.Is(err, emailscraper.ErrNoPagesScraped) {
+		t.Fatalf("expected ErrNoPagesScraped, got %v", err)
+	}
+
+	// one attempt plus two retries
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+
+	if result.Stats.FailedRequests < 3 {
+		t.Errorf("expected at least 3 failed requests, got %d", result.Stats.FailedRequests)
+	}
+}
+
+func TestScrapeEvaluateScripts(t *testing.T) {
+	t.Parallel()
+
+	server := newTestSite(t, map[string]string{
+		"/": `<script>document.write("<a href=\"mailto:" + ["org", "example", "info@"].reverse().join(".")` +
+			`.replace("@.", "@") + "\">Mail</a>")</script>`,
+	})
+
+	cfg := testConfig()
+	cfg.EvaluateScripts = true
+
+	result, err := emailscraper.New(cfg).ScrapeDetailed(t.Context(), server.URL)
+	if err != nil {
+		t.Fatalf("ScrapeDetailed() error: %v", err)
+	}
+
+	if len(result.Findings) != 1 || result.Findings[0].Email != "info@example.org" {
+		t.Fatalf("findings = %+v, want info@example.org", result.Findings)
+	}
+
+	if result.Findings[0].Method != emailscraper.MethodScript {
+		t.Errorf("method = %q, want %q", result.Findings[0].Method, emailscraper.MethodScript)
+	}
+}
+
+func TestScrapeMailtoLinks(t *testing.T) {
+	t.Parallel()
+
+	server := newTestSite(t, map[string]string{
+		"/": `<p>Questions? <a href="mailto:sales%2Beu&#64;example.com?subject=Pricing">Ask sales</a></p>`,
+	})
+
+	result, err := emailscraper.New(testConfig()).ScrapeDetailed(t.Context(), server.URL)
+	if err != nil {
+		t.Fatalf("ScrapeDetailed() error: %v", err)
+	}
+
+	if len(result.Findings) != 1 {
+		t.Fatalf("findings = %+v, want one", result.Findings)
+	}
+
+	finding := result.Findings[0]
+	if finding.Email != "sales+eu@example.com" || finding.Method != emailscraper.MethodMailto ||
+		finding.DisplayName != "Ask sales" || finding.Context != "Pricing" {
+		t.Errorf("unexpected finding: %+v", finding)
+	}
+}
+
+// mxResolver resolves every domain to a single mail host, mx.<domain>.
+type mxResolver struct{}
+
+func (mxResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	return []*net.MX{{Host: "mx." + name + ".", Pref: 10}}, nil
+}
+
+func (mxResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	return nil, nil
+}
+
+func TestScrapeVerifyDomains(t *testing.T) {
+	t.Parallel()
+
+	server := newTestSite(t, map[string]string{"/": `<p>Write to sales@example.com</p>`})
+
+	cfg := testConfig()
+	cfg.VerifyDomains = true
+	cfg.Verification.Resolver = mxResolver{}
+
+	stream := emailscraper.New(cfg).ScrapeStream(t.Context(), server.URL)
+
+	for finding := range stream.Findings() {
+		verification := finding.Verification
+		if verification.Domain != emailscraper.DomainMX || !slices.Equal(verification.MailHosts, []string{"mx.example.com"}) {
+			t.Errorf("streamed verification = %+v, want mx.example.com", verification)
+		}
+	}
+
+	result, err := stream.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error: %v", err)
+	}
+
+	if len(result.Findings) != 1 || result.Findings[0].Verification.Domain != emailscraper.DomainMX {
+		t.Errorf("findings = %+v, want one with MX records", result.Findings)
+	}
+}
+
+// slowResolver resolves like mxResolver after a delay.
+type slowResolver struct {
+	mxResolver
+
+	delay time.Duration
+}
+
+func (r slowResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return r.mxResolver.LookupMX(ctx, name)
+}
+
+func TestScrapeVerificationDoesNotHoldUpTheCrawl(t *testing.T) {
+	t.Parallel()
+
+	const delay = 300 * time.Millisecond
+
+	server := newTestSite(t, map[string]string{
+		"/":     `<p>sales@one.example.com, sales@two.example.com</p><a href="/team">Team</a>`,
+		"/team": `<p>sales@three.example.com, sales@four.example.com</p>`,
+	})
+
+	cfg := testConfig()
+	cfg.VerifyDomains = true
+	cfg.Verification.Resolver = slowResolver{mxResolver: mxResolver{}, delay: delay}
+
+	started := time.Now()
+
+	result, err := emailscraper.New(cfg).ScrapeDetailed(t.Context(), server.URL)
+	if err != nil {
+		t.Fatalf("ScrapeDetailed() error: %v", err)
+	}
+
+	// Verifying each new domain inside the page callbacks would add up the delays of a page
+	if elapsed := time.Since(started); elapsed >= 2*delay {
+		t.Errorf("scrape took %v, want the lookups of %d domains to overlap", elapsed, len(result.Findings))
+	}
+
+	if len(result.Findings) != 4 {
+		t.Fatalf("findings = %+v, want 4", result.Findings)
+	}
+
+	for _, finding := range result.Findings {
+		if finding.Verification.Domain != emailscraper.DomainMX {
+			t.Errorf("%s verification = %+v, want MX records", finding.Email, finding.Verification)
+		}
+	}
+}
+
+func TestScrapeMailboxVerificationDoesNotHoldUpTheCrawl(t *testing.T) {
+	t.Parallel()
+
+	const delay = 300 * time.Millisecond
+
+	server := newTestSite(t, map[string]string{
+		"/": `<p>sales@one.example.com, sales@two.example.com, sales@three.example.com</p>`,
+	})
+
+	cfg := testConfig()
+	cfg.VerifyMailboxes = true
+	cfg.Verification.Resolver = mxResolver{}
+	// Mail hosts that take a while to turn the verifier away
+	cfg.SMTP.Dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+
+		return nil, net.ErrClosed
+	}
+
+	started := time.Now()
+
+	result, err := emailscraper.New(cfg).ScrapeDetailed(t.Context(), server.URL)
+	if err != nil {
+		t.Fatalf("ScrapeDetailed() error: %v", err)
+	}
+
+	if elapsed := time.Since(started); elapsed >= 2*delay {
+		t.Errorf("scrape took %v, want the conversations with %d domains to overlap", elapsed, len(result.Findings))
+	}
+
+	if len(result.Findings) != 3 {
+		t.Fatalf("findings = %+v, want 3", result.Findings)
+	}
+
+	for _, finding := range result.Findings {
+		if finding.Verification.Mailbox != emailscraper.MailboxUnknown {
+			t.Errorf("%s mailbox = %q, want unknown", finding.Email, finding.Verification.Mailbox)
+		}
+	}
+}
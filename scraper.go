@@ -1,10 +1,8 @@
 package emailscraper
 
 import (
-	"errors"
 	"log"
 	"os"
-	"sync"
 	"time"
 
 	"github.com/gocolly/colly/v2"
@@ -16,10 +14,12 @@ const (
 	defaultMaxDepth = 3
 	// defaultTimeoutSeconds is the default timeout in seconds for scraping operations.
 	defaultTimeoutSeconds = 30
-	// defaultRateLimitDelay is the default delay between requests to the same domain.
+	// defaultRateLimitDelay is the default delay between the requests of a scrape.
 	defaultRateLimitDelay = 100 * time.Millisecond
-	// defaultParallelism is the default number of concurrent requests per domain.
+	// defaultParallelism is the default number of concurrent requests per scrape.
 	defaultParallelism = 2
+	// defaultBatchConcurrency is the default number of sites scraped at once by batch scrapes.
+	defaultBatchConcurrency = 8
 	// defaultMaxRetries is the default number of retry attempts for failed requests.
 	defaultMaxRetries = 3
 	// defaultRetryDelay is the default initial delay between retries.
@@ -34,11 +34,17 @@ const (
 )
 
 // Scraper config.
+//
+// A Scraper is safe for concurrent use: every scrape runs in its own session with its own collector,
+// so rate limits, redirect checks and the robots.txt cache apply per site, while the HTTP transport
+// and the browser pool are shared.
 type Scraper struct {
 	cfg Config
 
-	collector *colly.Collector
-	emailsSet *emails
+	browsers  *browserPool
+	extractor *extractor
+	// verifier is nil unless VerifyDomains or VerifyMailboxes is set.
+	verifier *verifier
 }
 
 // Config for the scraper.
@@ -54,10 +60,51 @@ type Config struct {
 	MaxRetries int
 	RetryDelay time.Duration
 
+	// BatchConcurrency limits how many sites ScrapeMany and ScrapeBatch crawl at once.
+	BatchConcurrency int
+
+	// Chrome configures the browser pool used when EnableJavascript is set.
+	Chrome ChromeConfig
+
+	// ExtraSeparatorVocabularies add words, per language, written instead of "@" and "." in obfuscated
+	// addresses such as "info arroba example punto es". The built-in DefaultSeparatorVocabularies are
+	// always understood.
+	ExtraSeparatorVocabularies []SeparatorVocabulary
+
+	// DomainForm selects whether internationalized domains are reported in Unicode or ASCII (punycode).
+	DomainForm DomainForm
+
+	// Validation selects how strictly extracted addresses are checked against RFC 5321 and RFC 5322.
+	// Set Debug to log why addresses are rejected.
+	Validation ValidationMode
+
+	// Normalize configures how addresses are normalized, and so which spellings count as the same address.
+	Normalize NormalizeConfig
+
+	// FalsePositives drops matches that are written like addresses but are not, such as logo@2x.png,
+	// package@1.2.3 or yourname@domain.com.
+	FalsePositives FalsePositiveConfig
+
+	// Verification configures the DNS lookups enabled by VerifyDomains, which resolve the mail hosts
+	// (MX records, or else A/AAAA records) of every unique domain found and record them on each Finding.
+	Verification VerificationConfig
+
+	// SMTP configures the mailbox checks enabled by VerifyMailboxes, which ask the mail hosts of each
+	// domain whether they accept mail for an address, probing a made-up address to detect catch-all
+	// domains. Domains are verified first even without VerifyDomains.
+	SMTP SMTPConfig
+
+	// Scripts limits the inline script evaluation enabled by EvaluateScripts, which runs inline scripts
+	// in a lightweight sandbox without Chrome and scans what they write or return.
+	Scripts ScriptConfig
+
 	// Behavior flags
 	Recursively         bool
 	Async               bool
 	EnableJavascript    bool
+	EvaluateScripts     bool
+	VerifyDomains       bool
+	VerifyMailboxes     bool
 	FollowExternalLinks bool
 	RespectRobotsTxt    bool
 	Debug               bool
@@ -66,42 +113,64 @@ type Config struct {
 // DefaultConfig defines default config with sane defaults for most use cases.
 func DefaultConfig() Config {
 	return Config{
-		MaxDepth:            defaultMaxDepth,
-		Timeout:             defaultTimeoutSeconds,
-		RateLimitDelay:      defaultRateLimitDelay,
-		Parallelism:         defaultParallelism,
-		MaxRetries:          defaultMaxRetries,
-		RetryDelay:          defaultRetryDelay,
-		Recursively:         true,
-		Async:               true,
-		EnableJavascript:    true,
-		FollowExternalLinks: false,
-		RespectRobotsTxt:    true,
-		Debug:               false,
+		MaxDepth:                   defaultMaxDepth,
+		Timeout:                    defaultTimeoutSeconds,
+		RateLimitDelay:             defaultRateLimitDelay,
+		Parallelism:                defaultParallelism,
+		MaxRetries:                 defaultMaxRetries,
+		RetryDelay:                 defaultRetryDelay,
+		BatchConcurrency:           defaultBatchConcurrency,
+		Chrome:                     DefaultChromeConfig(),
+		Scripts:                    DefaultScriptConfig(),
+		ExtraSeparatorVocabularies: nil,
+		DomainForm:                 DomainAsFound,
+		Validation:                 ValidationLenient,
+		Normalize:                  DefaultNormalizeConfig(),
+		FalsePositives:             DefaultFalsePositiveConfig(),
+		Verification:               DefaultVerificationConfig(),
+		SMTP:                       DefaultSMTPConfig(),
+		Recursively:                true,
+		Async:                      true,
+		EnableJavascript:           true,
+		EvaluateScripts:            false,
+		VerifyDomains:              false,
+		VerifyMailboxes:            false,
+		FollowExternalLinks:        false,
+		RespectRobotsTxt:           true,
+		Debug:                      false,
 	}
 }
 
 // New initiates new scraper entity.
 func New(cfg Config) *Scraper {
+	var domains *verifier
+	if cfg.VerifyDomains || cfg.VerifyMailboxes {
+		domains = newVerifier(cfg)
+	}
+
+	return &Scraper{
+		cfg:       cfg,
+		browsers:  newBrowserPool(cfg.Chrome),
+		extractor: newExtractor(cfg),
+		verifier:  domains,
+	}
+}
+
+// Close stops the browsers started for JavaScript rendering. The scraper can not render pages afterwards.
+func (s *Scraper) Close() error {
+	return s.browsers.close()
+}
+
+// newCollector returns a collector configured for a single scrape. Each scrape needs its own: colly
+// keeps rate limits and the redirect check, which enforces AllowedDomains, in the collector backend.
+func newCollector(cfg Config) *colly.Collector {
 	collector := colly.NewCollector(
 		colly.UserAgent(defaultUserAgent),
 	)
 
 	configureCollector(collector, cfg)
 
-	scraper := &Scraper{
-		cfg:       cfg,
-		collector: collector,
-		emailsSet: &emails{
-			set: nil,
-			m:   sync.Mutex{},
-		},
-	}
-
-	scraper.configureRetry()
-	scraper.configureCallbacks()
-
-	return scraper
+	return collector
 }
 
 // configureCollector sets up the collector with basic settings.
@@ -145,43 +214,6 @@ func configureRateLimiting(collector *colly.Collector, cfg Config) {
 	})
 }
 
-// configureRetry sets up retry with exponential backoff.
-func (s *Scraper) configureRetry() {
-	if s.cfg.MaxRetries <= 0 {
-		return
-	}
-
-	s.collector.OnError(func(response *colly.Response, err error) {
-		s.handleRequestError(response, err)
-	})
-
-	s.collector.OnRequest(func(request *colly.Request) {
-		request.Ctx.Put("retriesLeft", s.cfg.MaxRetries)
-	})
-}
-
-// handleRequestError handles errors during requests and implements retry logic.
-func (s *Scraper) handleRequestError(response *colly.Response, err error) {
-	retriesLeft, ok := response.Ctx.GetAny("retriesLeft").(int)
-	if !ok {
-		retriesLeft = s.cfg.MaxRetries
-	}
-
-	if retriesLeft <= 0 {
-		s.log("request to", response.Request.URL, "failed after", s.cfg.MaxRetries, "retries:", err)
-
-		return
-	}
-
-	delay := s.calculateRetryDelay(retriesLeft)
-
-	s.log("retrying request to", response.Request.URL, "in", delay, "(", retriesLeft, "retries left)")
-	time.Sleep(delay)
-
-	response.Ctx.Put("retriesLeft", retriesLeft-1)
-	_ = response.Request.Retry()
-}
-
 // calculateRetryDelay computes the delay for the current retry attempt using exponential backoff.
 func (s *Scraper) calculateRetryDelay(retriesLeft int) time.Duration {
 	attempt := s.cfg.MaxRetries - retriesLeft + 1
@@ -202,50 +234,6 @@ func (s *Scraper) calculateRetryDelay(retriesLeft int) time.Duration {
 	return min(delay, maxRetryDelay)
 }
 
-// configureCallbacks sets up all the collector callbacks for scraping.
-func (s *Scraper) configureCallbacks() {
-	if s.cfg.EnableJavascript {
-		s.collector.OnResponse(func(response *colly.Response) {
-			err := initiateScrapingFromChrome(response, s.cfg.Timeout)
-			if err != nil {
-				s.log(err)
-
-				return
-			}
-		})
-	}
-
-	// Parse emails on each downloaded page
-	s.collector.OnScraped(func(response *colly.Response) {
-		s.emailsSet.parseEmails(response.Body)
-	})
-
-	// Cloudflare encoded email support
-	s.collector.OnHTML("span[data-cfemail]", func(el *colly.HTMLElement) {
-		s.emailsSet.parseCloudflareEmail(el.Attr("data-cfemail"))
-	})
-
-	if s.cfg.Recursively {
-		s.configureRecursiveCrawling()
-	}
-}
-
-// configureRecursiveCrawling sets up link following for recursive scraping.
-func (s *Scraper) configureRecursiveCrawling() {
-	s.collector.OnHTML("a[href]", func(el *colly.HTMLElement) {
-		s.log("visiting: ", el.Attr("href"))
-
-		err := el.Request.Visit(el.Attr("href"))
-		if err != nil {
-			// Ignore already visited error, this appears too often
-			var alreadyVisited *colly.AlreadyVisitedError
-			if !errors.As(err, &alreadyVisited) {
-				s.log("error while linking: ", err.Error())
-			}
-		}
-	})
-}
-
 func (s *Scraper) log(v ...any) {
 	if s.cfg.Debug {
 		log.Println(v...)
@@ -0,0 +1,515 @@
+package emailscraper
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/gocolly/colly/v2"
+)
+
+const (
+	// defaultScriptTimeout is the default run time of a single inline script.
+	defaultScriptTimeout = 250 * time.Millisecond
+	// defaultScriptMaxMemory is the default memory a single inline script may allocate.
+	defaultScriptMaxMemory = 32 << 20
+	// defaultScriptMaxSize is the default size above which inline scripts are not evaluated.
+	defaultScriptMaxSize = 32 << 10
+	// defaultScriptMaxOutput is the default number of output bytes kept from a single inline script.
+	defaultScriptMaxOutput = 1 << 20
+	// scriptMaxCallStackSize bounds recursion in evaluated scripts.
+	scriptMaxCallStackSize = 512
+	// scriptMemoryPollInterval is how often the process heap is checked while a script runs.
+	scriptMemoryPollInterval = 5 * time.Millisecond
+	// heapObjectsMetric is the runtime metric the process heap guard of scripts checks.
+	heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+)
+
+var (
+	errScriptTimeout = errors.New("script timed out")
+	errScriptMemory  = errors.New("script exceeded its memory limit")
+	errScriptOutput  = errors.New("script exceeded its output limit")
+)
+
+// ScriptConfig limits the lightweight evaluation of inline scripts enabled by Config.EvaluateScripts.
+type ScriptConfig struct {
+	// Timeout bounds the run time of a single script.
+	Timeout time.Duration
+	// MaxMemory bounds the memory a script may allocate. Built-ins that allocate a whole string or buffer
+	// at once, such as "x".repeat(1e9), fail when their result would exceed it. Gradual growth is caught
+	// by a guard that stops the script once the heap of the whole process has grown by this many bytes.
+	// Go can not attribute heap growth to a single script, so that guard is only accurate while a single
+	// scrape runs: allocations of concurrent scrapes or of the rest of the program count against every
+	// running script, and a garbage collection during a script may hide what it allocated.
+	MaxMemory uint64
+	// MaxScriptSize skips inline scripts larger than this many bytes, such as bundled libraries.
+	MaxScriptSize int
+	// MaxOutputSize stops a script once it has written this many bytes.
+	MaxOutputSize int
+}
+
+// DefaultScriptConfig defines the inline script evaluation defaults.
+func DefaultScriptConfig() ScriptConfig {
+	return ScriptConfig{
+		Timeout:       defaultScriptTimeout,
+		MaxMemory:     defaultScriptMaxMemory,
+		MaxScriptSize: defaultScriptMaxSize,
+		MaxOutputSize: defaultScriptMaxOutput,
+	}
+}
+
+var (
+	// Matches script elements with their attributes and source.
+	scriptElements = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+
+	// Matches the src attribute of external scripts.
+	scriptSrcAttr = regexp.MustCompile(`(?i)\bsrc\s*=`)
+
+	// Matches the type attribute of script elements.
+	scriptTypeAttr = regexp.MustCompile(`(?i)\btype\s*=\s*["']?([^"'\s>]+)`)
+
+	// Matches constructs used by scripts that assemble emails.
+	scriptObfuscationHints = regexp.MustCompile(`(?i)document\.write|fromCharCode|reverse\(|atob\(|unescape\(` +
+		`|decodeURIComponent\(|innerHTML|mailto|@|\\x40|\\u0040|&#0*64;`)
+)
+
+// inlineScripts returns the classic inline scripts of an HTML body that look like they assemble emails.
+func inlineScripts(body []byte, maxSize int) []string {
+	scripts := make([]string, 0)
+
+	for _, match := range scriptElements.FindAllSubmatch(body, -1) {
+		attrs, source := match[1], match[2]
+
+		if scriptSrcAttr.Match(attrs) {
+			continue
+		}
+
+		// Modules, JSON and templates are not classic scripts
+		if typeAttr := scriptTypeAttr.FindSubmatch(attrs); typeAttr != nil &&
+			!strings.Contains(strings.ToLower(string(typeAttr[1])), "javascript") {
+			continue
+		}
+
+		if maxSize > 0 && len(source) > maxSize {
+			continue
+		}
+
+		if !scriptObfuscationHints.Match(source) {
+			continue
+		}
+
+		// Legacy scripts are often wrapped in HTML comments
+		text := strings.TrimSpace(string(source))
+		text = strings.TrimPrefix(text, "<!--")
+		text = strings.TrimSuffix(text, "-->")
+
+		scripts = append(scripts, text)
+	}
+
+	return scripts
+}
+
+// evaluateInlineScripts runs the inline scripts of an HTML response and scans whatever they write or return.
+func (sess *session) evaluateInlineScripts(response *colly.Response) {
+	s := sess.scraper
+
+	if !isHTMLResponse(response) {
+		return
+	}
+
+	for _, source := range inlineScripts(response.Body, s.cfg.Scripts.MaxScriptSize) {
+		output, err := runInlineScript(sess.ctx, s.cfg.Scripts, source, response.Request.URL)
+		if err != nil {
+			s.log("inline script on", response.Request.URL, "failed:", err)
+		}
+
+		// Output written before a failure is still scanned
+		if output != "" {
+			sess.emailsSet.parseEmailsAs([]byte(output), requestOrigin(response.Request), MethodScript)
+		}
+
+		if sess.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// scriptOutput collects what a script writes, up to a limit.
+type scriptOutput struct {
+	builder strings.Builder
+	limit   int
+}
+
+// write appends text to the document stream, which like document.write joins consecutive calls
+// without a separator. It reports whether the output is still within its limit.
+func (o *scriptOutput) write(text string) bool {
+	if o.limit > 0 && o.builder.Len()+len(text) > o.limit {
+		o.builder.WriteString(text[:max(o.limit-o.builder.Len(), 0)])
+
+		return false
+	}
+
+	o.builder.WriteString(text)
+
+	return true
+}
+
+// capture appends text from any other sink, such as element content, the console or the completion
+// value, on a line of its own so it does not run into the document stream or other captures.
+func (o *scriptOutput) capture(text string) bool {
+	if o.builder.Len() > 0 && !strings.HasSuffix(o.builder.String(), "\n") {
+		text = "\n" + text
+	}
+
+	return o.write(text + "\n")
+}
+
+// runInlineScript evaluates source in a fresh sandbox with a stubbed document and window. It returns
+// everything the script wrote to the document, to elements or to the console, plus its completion value.
+func runInlineScript(ctx context.Context, cfg ScriptConfig, source string, page *url.URL) (string, error) {
+	vm := goja.New()
+	vm.SetMaxCallStackSize(scriptMaxCallStackSize)
+
+	output := &scriptOutput{builder: strings.Builder{}, limit: cfg.MaxOutputSize}
+
+	err := setupScriptSandbox(vm, output, page, cfg.MaxMemory)
+	if err != nil {
+		return "", err
+	}
+
+	stop := watchScript(ctx, vm, cfg)
+
+	value, err := vm.RunScript("inline", source)
+	if err == nil {
+		if text, ok := value.Export().(string); ok {
+			output.capture(text)
+		}
+
+		// Load handlers and timers run once the script itself has finished
+		_, err = vm.RunString("__flush()")
+	}
+
+	stop()
+
+	if err != nil {
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			if cause, ok := interrupted.Value().(error); ok {
+				err = cause
+			}
+		}
+
+		return output.builder.String(), fmt.Errorf("evaluating inline script: %w", err)
+	}
+
+	return output.builder.String(), nil
+}
+
+// watchScript interrupts vm once ctx is done, the script exceeds its time limit or the process heap
+// grows by more than its memory limit. The returned function stops watching.
+func watchScript(ctx context.Context, vm *goja.Runtime, cfg ScriptConfig) func() {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultScriptTimeout
+	}
+
+	timer := time.AfterFunc(timeout, func() { vm.Interrupt(errScriptTimeout) })
+	stopAfter := context.AfterFunc(ctx, func() { vm.Interrupt(ctx.Err()) })
+
+	done := make(chan struct{})
+
+	var watching sync.WaitGroup
+
+	if cfg.MaxMemory > 0 {
+		baseline := heapObjectsBytes()
+
+		watching.Go(func() {
+			ticker := time.NewTicker(scriptMemoryPollInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if heapObjectsBytes() > baseline+cfg.MaxMemory {
+						vm.Interrupt(errScriptMemory)
+
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		})
+	}
+
+	return func() {
+		timer.Stop()
+		stopAfter()
+		close(done)
+		watching.Wait()
+	}
+}
+
+// heapObjectsBytes returns the memory occupied by heap objects.
+func heapObjectsBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapObjectsMetric, Value: metrics.Value{}}}
+	metrics.Read(sample)
+
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
+
+// setupScriptSandbox installs the document, window and helper stubs inline scripts expect. With a
+// memory limit, built-ins that allocate their whole result at once are bounded by it.
+func setupScriptSandbox(vm *goja.Runtime, output *scriptOutput, page *url.URL, maxMemory uint64) error {
+	err := vm.Set("__write", func(text string) {
+		if !output.write(text) {
+			vm.Interrupt(errScriptOutput)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("installing script sandbox: %w", err)
+	}
+
+	err = vm.Set("__capture", func(text string) {
+		if !output.capture(text) {
+			vm.Interrupt(errScriptOutput)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("installing script sandbox: %w", err)
+	}
+
+	err = vm.Set("__location", map[string]any{
+		"href":     page.String(),
+		"protocol": page.Scheme + ":",
+		"host":     page.Host,
+		"hostname": page.Hostname(),
+		"pathname": page.Path,
+		"search":   page.RawQuery,
+		"hash":     page.Fragment,
+		"origin":   page.Scheme + "://" + page.Host,
+	})
+	if err != nil {
+		return fmt.Errorf("installing script sandbox: %w", err)
+	}
+
+	err = vm.Set("atob", func(encoded string) (string, error) {
+		decoded, decodeErr := base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if decodeErr != nil {
+			return "", fmt.Errorf("atob: %w", decodeErr)
+		}
+
+		// atob returns a binary string with one character per byte
+		runes := make([]rune, len(decoded))
+		for i, b := range decoded {
+			runes[i] = rune(b)
+		}
+
+		return string(runes), nil
+	})
+	if err != nil {
+		return fmt.Errorf("installing script sandbox: %w", err)
+	}
+
+	_, err = vm.RunString(scriptSandboxPrelude)
+	if err != nil {
+		return fmt.Errorf("installing script sandbox: %w", err)
+	}
+
+	if maxMemory > 0 {
+		err = boundScriptAllocations(vm, maxMemory)
+		if err != nil {
+			return fmt.Errorf("installing script sandbox: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// boundScriptAllocations makes the built-ins that allocate a whole string, array or buffer in one call
+// fail when their result would take more than limit bytes. Such allocations happen between two polls
+// of the process heap guard, so it would not see them in time.
+func boundScriptAllocations(vm *goja.Runtime, limit uint64) error {
+	err := vm.Set("__allocating", func(bytes float64) {
+		if bytes > float64(limit) {
+			// Interrupting as well stops scripts that catch the exception
+			vm.Interrupt(errScriptMemory)
+			panic(vm.NewGoError(errScriptMemory))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("bounding allocations: %w", err)
+	}
+
+	_, err = vm.RunString(scriptAllocationGuards)
+	if err != nil {
+		return fmt.Errorf("bounding allocations: %w", err)
+	}
+
+	return nil
+}
+
+// scriptAllocationGuards wraps the built-ins whose result size follows from their arguments, and so
+// can be checked before they allocate. Sizes are upper bounds: characters may take two bytes.
+const scriptAllocationGuards = `(() => {
+	const allocating = __allocating;
+	const count = (value) => Math.max(Number(value) || 0, 0);
+
+	const guard = (object, name, size) => {
+		const original = object[name];
+		Object.defineProperty(object, name, {
+			value: function (...args) {
+				allocating(size(this, args));
+
+				return original.apply(this, args);
+			},
+			writable: true,
+			configurable: true,
+		});
+	};
+
+	guard(String.prototype, "repeat", (text, [times]) => 2 * String(text).length * count(times));
+	guard(String.prototype, "padStart", (text, [length]) => 2 * count(length));
+	guard(String.prototype, "padEnd", (text, [length]) => 2 * count(length));
+	guard(Array.prototype, "join", (array, [separator]) =>
+		2 * count(array.length) * (separator === undefined ? 1 : String(separator).length + 1));
+	guard(Array.prototype, "fill", (array) => 16 * count(array.length));
+
+	const buffers = [
+		"ArrayBuffer", "Int8Array", "Uint8Array", "Uint8ClampedArray", "Int16Array", "Uint16Array",
+		"Int32Array", "Uint32Array", "Float32Array", "Float64Array",
+	];
+
+	for (const name of buffers) {
+		const original = globalThis[name];
+		if (typeof original !== "function") continue;
+
+		globalThis[name] = new Proxy(original, {
+			construct: (target, args, newTarget) => {
+				if (typeof args[0] === "number") allocating(args[0] * (target.BYTES_PER_ELEMENT || 1));
+
+				return Reflect.construct(target, args, newTarget);
+			},
+		});
+	}
+
+	delete globalThis.__allocating;
+})();`
+
+// scriptSandboxPrelude stubs the parts of the DOM that obfuscation scripts use. Everything written to
+// the document, to element content or attributes, to the console or to alerts is captured; document
+// writes are joined like the parser would, so an address may be written piece by piece.
+const scriptSandboxPrelude = `(() => {
+	const text = (parts) => parts.map(String).join("");
+	const capture = (...parts) => __capture(text(parts));
+	const pending = [];
+	const queue = (callback) => {
+		if (typeof callback === "function") pending.push(callback);
+	};
+
+	const element = () => {
+		const node = {
+			style: {},
+			dataset: {},
+			children: [],
+			childNodes: [],
+			classList: {add() {}, remove() {}, toggle() {}, contains: () => false},
+			setAttribute: (name, value) => capture(value),
+			getAttribute: () => null,
+			appendChild: (child) => child,
+			insertBefore: (child) => child,
+			replaceChild: (child) => child,
+			removeChild: (child) => child,
+			append: (...nodes) => nodes.forEach((part) => typeof part === "string" && capture(part)),
+			insertAdjacentHTML: (position, html) => capture(html),
+			insertAdjacentText: (position, text) => capture(text),
+			addEventListener: (type, callback) => queue(callback),
+			removeEventListener() {},
+			querySelector: () => element(),
+			querySelectorAll: () => [],
+			getElementsByTagName: () => [],
+			remove() {},
+			click() {},
+		};
+
+		for (const property of ["innerHTML", "outerHTML", "innerText", "textContent", "href", "value", "title"]) {
+			let stored = "";
+			Object.defineProperty(node, property, {
+				get: () => stored,
+				set: (value) => {
+					stored = String(value);
+					capture(stored);
+				},
+			});
+		}
+
+		Object.defineProperty(node, "parentNode", {get: () => element()});
+		Object.defineProperty(node, "parentElement", {get: () => element()});
+
+		return node;
+	};
+
+	globalThis.window = globalThis;
+	globalThis.self = globalThis;
+	globalThis.location = __location;
+	globalThis.navigator = {userAgent: "Mozilla/5.0", language: "en-US", languages: ["en-US"]};
+	globalThis.console = {log: capture, info: capture, warn: capture, error: capture, debug: capture};
+	globalThis.alert = capture;
+	globalThis.setTimeout = (callback) => {
+		queue(callback);
+
+		return 0;
+	};
+	globalThis.setInterval = globalThis.setTimeout;
+	globalThis.clearTimeout = () => {};
+	globalThis.clearInterval = () => {};
+	globalThis.addEventListener = (type, callback) => queue(callback);
+
+	globalThis.document = {
+		write: (...parts) => __write(text(parts)),
+		writeln: (...parts) => __write(text(parts) + "\n"),
+		getElementById: () => element(),
+		getElementsByTagName: () => [element()],
+		getElementsByClassName: () => [],
+		getElementsByName: () => [],
+		querySelector: () => element(),
+		querySelectorAll: () => [],
+		createElement: () => element(),
+		createTextNode: (text) => {
+			capture(text);
+
+			return element();
+		},
+		addEventListener: (type, callback) => queue(callback),
+		body: element(),
+		head: element(),
+		documentElement: element(),
+		currentScript: element(),
+		readyState: "complete",
+		cookie: "",
+		location: __location,
+	};
+
+	globalThis.__flush = () => {
+		queue(globalThis.onload);
+		for (let i = 0; i < pending.length && i < 100; i++) {
+			try {
+				pending[i]();
+			} catch (error) {
+				// A failing handler does not stop the others
+			}
+		}
+	};
+})();`
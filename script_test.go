@@ -0,0 +1,183 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunInlineScript(t *testing.T) {
+	t.Parallel()
+
+	page, err := url.Parse("https://example.com/contact")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		source     string
+		wantOutput string
+		wantErr    error
+		// wantFailure expects an error other than the limits, such as an exception of the script.
+		wantFailure bool
+	}{
+		{
+			name:       "document.write concatenation",
+			source:     `var user = "info"; document.write("<a href='mailto:" + user + "&#64;" + location.hostname + "'>");`,
+			wantOutput: "mailto:info&#64;example.com",
+			wantErr:    nil,
+		},
+		{
+			name:       "address written piece by piece",
+			source:     `var user = "jane"; document.write(user); document.write("@"); document.write("acme.org");`,
+			wantOutput: "jane@acme.org",
+			wantErr:    nil,
+		},
+		{
+			name:       "separate sinks",
+			source:     `document.writeln("one"); document.write("two"); console.log("three"); document.write("four"); "five"`,
+			wantOutput: "one\ntwo\nthree\nfour\nfive\n",
+			wantErr:    nil,
+		},
+		{
+			name:       "fromCharCode",
+			source:     `document.write(String.fromCharCode(106, 111, 101, 64, 101, 120, 46, 111, 114, 103))`,
+			wantOutput: "joe@ex.org",
+			wantErr:    nil,
+		},
+		{
+			name:       "reversed completion value",
+			source:     `"gro.elpmaxe@selas".split("").reverse().join("")`,
+			wantOutput: "sales@example.org",
+			wantErr:    nil,
+		},
+		{
+			name:       "innerHTML on load",
+			source:     `window.onload = () => { document.getElementById("c").innerHTML = atob("aGlAZXhhbXBsZS5uZXQ="); };`,
+			wantOutput: "hi@example.net",
+			wantErr:    nil,
+		},
+		{
+			name:        "output kept before exception",
+			source:      `document.write("a@example.com"); jQuery("#x").show();`,
+			wantOutput:  "a@example.com",
+			wantErr:     nil,
+			wantFailure: true,
+		},
+		{
+			name:       "endless loop",
+			source:     `document.write("b@example.com"); while (true) {}`,
+			wantOutput: "b@example.com",
+			wantErr:    errScriptTimeout,
+		},
+		{
+			name:       "output limit",
+			source:     `for (;;) document.write("spam spam spam spam spam spam spam spam");`,
+			wantOutput: "spam",
+			wantErr:    errScriptOutput,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultScriptConfig()
+			cfg.Timeout = 100 * time.Millisecond
+			cfg.MaxOutputSize = 1 << 10
+
+			output, err := runInlineScript(t.Context(), cfg, testCase.source, page)
+
+			switch {
+			case testCase.wantErr != nil && !errors.Is(err, testCase.wantErr):
+				t.Errorf("runInlineScript() error = %v, want %v", err, testCase.wantErr)
+			case testCase.wantErr == nil && testCase.wantFailure && err == nil:
+				t.Error("runInlineScript() error = nil, want the exception of the script")
+			case testCase.wantErr == nil && !testCase.wantFailure && err != nil:
+				t.Errorf("runInlineScript() error = %v, want none", err)
+			}
+
+			if !strings.Contains(output, testCase.wantOutput) {
+				t.Errorf("runInlineScript() output = %q, want it to contain %q", output, testCase.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunInlineScriptMemoryLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"gradual growth", `var parts = []; for (;;) parts.push("x".repeat(1024) + parts.length);`},
+		{"repeat", `document.write("x".repeat(1e9))`},
+		{"caught repeat", `try { "x".repeat(1e9) } catch (error) {} for (;;) {}`},
+		{"padding", `document.write("".padEnd(1e9, "x"))`},
+		{"join", `document.write(new Array(1e9).join("x"))`},
+		{"fill", `new Array(1e9).fill(0)`},
+		{"typed array", `new Uint8Array(1e9)`},
+		{"array buffer", `new ArrayBuffer(1e9)`},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultScriptConfig()
+			cfg.Timeout = 10 * time.Second
+			cfg.MaxMemory = 8 << 20
+
+			_, err := runInlineScript(t.Context(), cfg, testCase.source, &url.URL{})
+			if !errors.Is(err, errScriptMemory) {
+				t.Errorf("runInlineScript() error = %v, want %v", err, errScriptMemory)
+			}
+		})
+	}
+}
+
+func TestRunInlineScriptSmallAllocations(t *testing.T) {
+	t.Parallel()
+
+	source := `document.write("ab".repeat(2) + "x".padStart(3, "-") + [1, 2].join("@") + new Uint8Array(4).length +
+		new Array(3).fill("y").join(""))`
+
+	output, err := runInlineScript(t.Context(), DefaultScriptConfig(), source, &url.URL{})
+	if err != nil {
+		t.Fatalf("runInlineScript() error = %v", err)
+	}
+
+	if want := "abab--x1@24yyy"; !strings.Contains(output, want) {
+		t.Errorf("runInlineScript() output = %q, want it to contain %q", output, want)
+	}
+}
+
+func TestInlineScripts(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`<script src="/app.js">document.write("a@b.c")</script>
+<script type="application/ld+json">{"email": "ld@example.com"}</script>
+<script type="module">import x from "./x.js"; document.write(x)</script>
+<script>var tracking = true;</script>
+<script type="text/javascript"><!--
+document.write("x" + "@" + "example.com");
+//--></script>
+<SCRIPT>document.write(String.fromCharCode(64))</SCRIPT>
+<script>document.write("` + strings.Repeat("x", 100) + `")</script>`)
+
+	got := inlineScripts(body, 64)
+	want := []string{
+		"\ndocument.write(\"x\" + \"@\" + \"example.com\");\n//",
+		"document.write(String.fromCharCode(64))",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("inlineScripts() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,295 @@
+package emailscraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// session holds the state of a single scrape: its own collector clone, visited URLs and emails.
+type session struct {
+	// ctx bounds the whole crawl; once it is done no new request, retry or render is started.
+	ctx     context.Context //nolint:containedctx // a session lives exactly as long as one scrape call
+	scraper *Scraper
+
+	collector *colly.Collector
+	emailsSet *emails
+	visited   *visitedSet
+	retries   *retryBudget
+	stats     *crawlStats
+}
+
+// newSession prepares a crawl of url; onFinding (when not nil) is called for every newly found email.
+func (s *Scraper) newSession(ctx context.Context, url string, onFinding func(Finding)) (*session, error) {
+	// URL revisits are tracked per session by visitedSet, which claims a URL before it is queued.
+	collector := newCollector(s.cfg)
+	collector.AllowURLRevisit = true
+	collector.Context = ctx
+
+	if !s.cfg.FollowExternalLinks {
+		allowedDomains, err := prepareAllowedDomain(url)
+		if err != nil {
+			return nil, err
+		}
+
+		collector.AllowedDomains = allowedDomains
+	}
+
+	sess := &session{
+		ctx:       ctx,
+		scraper:   s,
+		collector: collector,
+		emailsSet: newEmails(s.extractor, s.verification(ctx), onFinding),
+		visited: &visitedSet{
+			urls: nil,
+			m:    sync.Mutex{},
+		},
+		retries: &retryBudget{
+			left: nil,
+			max:  s.cfg.MaxRetries,
+			m:    sync.Mutex{},
+		},
+		stats: newCrawlStats(),
+	}
+
+	sess.configureCancellation()
+	sess.configureRetry()
+	sess.configureCallbacks()
+
+	return sess, nil
+}
+
+// verification returns the verification of newly found emails during a crawl bound by ctx, or nil
+// when verification is off.
+func (s *Scraper) verification(ctx context.Context) func(string) Verification {
+	if s.verifier == nil {
+		return nil
+	}
+
+	return func(email string) Verification {
+		return s.verifier.verify(ctx, email)
+	}
+}
+
+// visit queues url unless this session has already visited it.
+func (sess *session) visit(url string) error {
+	if !sess.visited.claim(url) {
+		return &colly.AlreadyVisitedError{Destination: nil}
+	}
+
+	return sess.collector.Visit(url) //nolint:wrapcheck // colly errors are descriptive enough
+}
+
+// result returns the findings and stats gathered so far.
+func (sess *session) result(url string, started time.Time) Result {
+	return Result{
+		URL:      url,
+		Findings: sess.emailsSet.findings(),
+		Stats:    sess.stats.snapshot(time.Since(started)),
+	}
+}
+
+// configureCancellation drops queued requests once the session context is done.
+func (sess *session) configureCancellation() {
+	sess.collector.OnRequest(func(request *colly.Request) {
+		if sess.ctx.Err() != nil {
+			request.Abort()
+		}
+	})
+}
+
+// configureRetry sets up retry with exponential backoff.
+func (sess *session) configureRetry() {
+	if sess.scraper.cfg.MaxRetries <= 0 {
+		return
+	}
+
+	sess.collector.OnError(func(response *colly.Response, err error) {
+		sess.handleRequestError(response, err)
+	})
+}
+
+// handleRequestError handles errors during requests and implements retry logic.
+func (sess *session) handleRequestError(response *colly.Response, err error) {
+	s := sess.scraper
+
+	if sess.ctx.Err() != nil {
+		return
+	}
+
+	retriesLeft := sess.retries.take(response.Request.URL.String())
+	if retriesLeft <= 0 {
+		s.log("request to", response.Request.URL, "failed after", s.cfg.MaxRetries, "retries:", err)
+
+		return
+	}
+
+	delay := s.calculateRetryDelay(retriesLeft)
+
+	s.log("retrying request to", response.Request.URL, "in", delay, "(", retriesLeft, "retries left)")
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-sess.ctx.Done():
+		s.log("retry of", response.Request.URL, "aborted:", sess.ctx.Err())
+
+		return
+	}
+
+	_ = response.Request.Retry()
+}
+
+// configureCallbacks sets up all the collector callbacks for scraping.
+func (sess *session) configureCallbacks() {
+	s := sess.scraper
+
+	if s.cfg.EnableJavascript {
+		sess.collector.OnResponse(func(response *colly.Response) {
+			if !shouldRender(s.cfg.Chrome.Mode, response) {
+				return
+			}
+
+			output, err := initiateScrapingFromChrome(sess.ctx, s.browsers, response, s.cfg.Timeout)
+			sess.stats.addBlocked(output.blocked)
+
+			if err != nil {
+				s.log(err)
+
+				return
+			}
+
+			sess.stats.rendered.Add(1)
+			sess.emailsSet.parseRendered(output, requestOrigin(response.Request))
+		})
+	}
+
+	sess.collector.OnError(func(_ *colly.Response, _ error) {
+		sess.stats.failed.Add(1)
+	})
+
+	// Parse emails on each downloaded page
+	sess.collector.OnScraped(func(response *colly.Response) {
+		sess.stats.pages.Add(1)
+		sess.emailsSet.parseEmails(response.Body, requestOrigin(response.Request))
+
+		if s.cfg.EvaluateScripts {
+			sess.evaluateInlineScripts(response)
+		}
+	})
+
+	// Addresses in mailto links may be encoded in ways the body regex does not match
+	sess.collector.OnHTML("a[href], area[href]", func(el *colly.HTMLElement) {
+		href := el.Attr("href")
+		if !hasMailtoScheme(href) {
+			return
+		}
+
+		sess.emailsSet.parseMailto(href, el.Text, requestOrigin(el.Request), el.DOM.Parent().Text())
+	})
+
+	// Cloudflare encoded email support
+	sess.collector.OnHTML("span[data-cfemail]", func(el *colly.HTMLElement) {
+		sess.emailsSet.parseCloudflareEmail(el.Attr("data-cfemail"), requestOrigin(el.Request), el.DOM.Parent().Text())
+	})
+
+	if s.cfg.Recursively {
+		sess.configureRecursiveCrawling()
+	}
+}
+
+// configureRecursiveCrawling sets up link following for recursive scraping.
+func (sess *session) configureRecursiveCrawling() {
+	s := sess.scraper
+
+	sess.collector.OnHTML("a[href]", func(el *colly.HTMLElement) {
+		link := el.Request.AbsoluteURL(el.Attr("href"))
+		if link == "" {
+			return
+		}
+
+		// Links beyond the depth limit are rejected by colly, keep them unclaimed
+		// so they can still be visited when found again on a shallower page.
+		if maxDepth := sess.collector.MaxDepth; maxDepth > 0 && el.Request.Depth >= maxDepth {
+			return
+		}
+
+		if !sess.visited.claim(link) {
+			return
+		}
+
+		s.log("visiting: ", link)
+
+		err := el.Request.Visit(link)
+		if err != nil {
+			// Ignore already visited error, this appears too often
+			var alreadyVisited *colly.AlreadyVisitedError
+			if !errors.As(err, &alreadyVisited) {
+				s.log("error while linking: ", err.Error())
+			}
+		}
+	})
+}
+
+// requestOrigin describes the page a colly request points to.
+func requestOrigin(request *colly.Request) origin {
+	return origin{
+		url:   request.URL.String(),
+		depth: request.Depth,
+	}
+}
+
+// visitedSet tracks the URLs a session has queued.
+type visitedSet struct {
+	urls map[string]struct{}
+	m    sync.Mutex
+}
+
+// claim marks url as visited and reports whether it was not visited before.
+func (v *visitedSet) claim(url string) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if v.urls == nil {
+		v.urls = make(map[string]struct{})
+	}
+
+	if _, ok := v.urls[url]; ok {
+		return false
+	}
+
+	v.urls[url] = struct{}{}
+
+	return true
+}
+
+// retryBudget tracks the retries left for each URL of a session.
+type retryBudget struct {
+	left map[string]int
+	max  int
+	m    sync.Mutex
+}
+
+// take returns the retries left for url before this attempt and consumes one of them.
+func (b *retryBudget) take(url string) int {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.left == nil {
+		b.left = make(map[string]int)
+	}
+
+	retriesLeft, ok := b.left[url]
+	if !ok {
+		retriesLeft = b.max
+	}
+
+	b.left[url] = max(retriesLeft-1, 0)
+
+	return retriesLeft
+}
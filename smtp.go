@@ -0,0 +1,309 @@
+package emailscraper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	// smtpPort is the port mail hosts accept mail on.
+	smtpPort = "25"
+	// defaultSMTPTimeout is the default time a whole SMTP conversation with a mail host may take.
+	defaultSMTPTimeout = 15 * time.Second
+	// defaultSMTPConnectionsPerDomain is the default number of concurrent SMTP conversations per domain.
+	defaultSMTPConnectionsPerDomain = 2
+	// fallbackHeloName is the name the verifier introduces itself with when no better one is known.
+	fallbackHeloName = "localhost"
+	// probeLocalPartBytes is the number of random bytes of the local part probed for catch-all domains.
+	probeLocalPartBytes = 12
+
+	// Reply codes that reject a recipient as unknown (RFC 5321 section 4.2.3).
+	smtpMailboxUnavailable    = 550
+	smtpUserNotLocal          = 551
+	smtpMailboxNameNotAllowed = 553
+)
+
+var errNoMailHost = errors.New("no reachable mail host")
+
+// SMTPConfig configures the mailbox verification enabled by VerifyMailboxes.
+type SMTPConfig struct {
+	// HeloName is the host name sent with EHLO; mail hosts are more willing to answer a resolvable one.
+	// Empty uses the domain of MailFrom, else the host name of the machine.
+	HeloName string
+	// MailFrom is the reverse path sent with MAIL FROM; empty sends the null path <>.
+	MailFrom string
+	// Timeout bounds a whole conversation with one mail host.
+	Timeout time.Duration
+	// MaxConnectionsPerDomain limits the concurrent conversations with the mail hosts of one domain.
+	MaxConnectionsPerDomain int
+	// Dial connects to a mail host; nil dials TCP. Replace it to go through a proxy, or to reach a fake
+	// server in tests.
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// DefaultSMTPConfig returns the default mailbox verification config.
+func DefaultSMTPConfig() SMTPConfig {
+	return SMTPConfig{
+		HeloName:                "",
+		MailFrom:                "",
+		Timeout:                 defaultSMTPTimeout,
+		MaxConnectionsPerDomain: defaultSMTPConnectionsPerDomain,
+		Dial:                    nil,
+	}
+}
+
+// MailboxStatus tells whether a mail host accepts mail for an address.
+type MailboxStatus string
+
+const (
+	// MailboxUnverified marks addresses whose mailbox was not verified.
+	MailboxUnverified MailboxStatus = ""
+	// MailboxDeliverable marks addresses the mail host accepts while rejecting made-up ones.
+	MailboxDeliverable MailboxStatus = "deliverable"
+	// MailboxUndeliverable marks addresses the mail host rejects as unknown, or at domains without mail.
+	MailboxUndeliverable MailboxStatus = "undeliverable"
+	// MailboxCatchAll marks addresses at domains that accept any address, so acceptance proves nothing.
+	MailboxCatchAll MailboxStatus = "catch-all"
+	// MailboxUnknown marks addresses the mail host gave no clear answer for, e.g. when greylisting,
+	// blocking the verifier or being unreachable.
+	MailboxUnknown MailboxStatus = "unknown"
+)
+
+// mailboxVerifier asks the mail hosts of domains whether they accept mail for addresses.
+type mailboxVerifier struct {
+	cfg SMTPConfig
+
+	m     sync.Mutex
+	slots map[string]*domainSlots
+}
+
+// domainSlots limits the conversations with the mail hosts of one domain. It is dropped once no
+// verification holds or waits for one of its slots.
+type domainSlots struct {
+	free    chan struct{}
+	holders int
+}
+
+// newMailboxVerifier prepares mailbox verification as configured.
+func newMailboxVerifier(cfg SMTPConfig) *mailboxVerifier {
+	if cfg.Dial == nil {
+		var dialer net.Dialer
+
+		cfg.Dial = dialer.DialContext
+	}
+
+	cfg.HeloName = heloName(cfg)
+
+	cfg.MaxConnectionsPerDomain = max(cfg.MaxConnectionsPerDomain, 1)
+
+	return &mailboxVerifier{
+		cfg:   cfg,
+		m:     sync.Mutex{},
+		slots: nil,
+	}
+}
+
+// heloName returns the name sent with EHLO: the configured one, else the domain of MailFrom, else the
+// host name of the machine. Many mail hosts turn away clients calling themselves localhost.
+func heloName(cfg SMTPConfig) string {
+	if cfg.HeloName != "" {
+		return cfg.HeloName
+	}
+
+	if at := strings.LastIndexByte(cfg.MailFrom, '@'); at >= 0 && at < len(cfg.MailFrom)-1 {
+		return cfg.MailFrom[at+1:]
+	}
+
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+
+	return fallbackHeloName
+}
+
+// verify classifies the mailbox of email, given the verification of its domain.
+func (v *mailboxVerifier) verify(ctx context.Context, email string, domain Verification) MailboxStatus {
+	switch domain.Domain {
+	case DomainMX, DomainAddressRecord:
+	case DomainNoMail:
+		return MailboxUndeliverable
+	default:
+		return MailboxUnknown
+	}
+
+	at := strings.LastIndexByte(email, '@')
+
+	domainName, err := idna.Lookup.ToASCII(email[at+1:])
+	if err != nil {
+		return MailboxUnknown
+	}
+
+	release, err := v.acquire(ctx, domainName)
+	if err != nil {
+		return MailboxUnknown
+	}
+	defer release()
+
+	recipient, probe := email[:at]+"@"+domainName, randomLocalPart()+"@"+domainName
+
+	for _, host := range domain.MailHosts {
+		status, err := v.ask(ctx, host, recipient, probe)
+		if err == nil {
+			return status
+		}
+	}
+
+	return MailboxUnknown
+}
+
+// acquire waits for a free conversation slot of domain; release returns it.
+func (v *mailboxVerifier) acquire(ctx context.Context, domain string) (func(), error) {
+	v.m.Lock()
+
+	if v.slots == nil {
+		v.slots = make(map[string]*domainSlots)
+	}
+
+	slots, ok := v.slots[domain]
+	if !ok {
+		slots = &domainSlots{free: make(chan struct{}, v.cfg.MaxConnectionsPerDomain), holders: 0}
+		v.slots[domain] = slots
+	}
+
+	slots.holders++
+
+	v.m.Unlock()
+
+	select {
+	case slots.free <- struct{}{}:
+		return func() {
+			<-slots.free
+			v.drop(domain, slots)
+		}, nil
+	case <-ctx.Done():
+		v.drop(domain, slots)
+
+		return nil, fmt.Errorf("waiting for an SMTP connection: %w", ctx.Err())
+	}
+}
+
+// drop ends a hold on the slots of domain and forgets them once nobody holds or waits for them.
+func (v *mailboxVerifier) drop(domain string, slots *domainSlots) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	slots.holders--
+	if slots.holders == 0 {
+		delete(v.slots, domain)
+	}
+}
+
+// ask runs EHLO, MAIL FROM and RCPT TO for recipient and then for probe, a made-up address at the same
+// domain, on host. It fails when host can not be talked to, so the next mail host can be tried.
+func (v *mailboxVerifier) ask(ctx context.Context, host, recipient, probe string) (MailboxStatus, error) {
+	if v.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, v.cfg.Timeout)
+		defer cancel()
+	}
+
+	conn, err := v.cfg.Dial(ctx, "tcp", net.JoinHostPort(host, smtpPort))
+	if err != nil {
+		return MailboxUnknown, fmt.Errorf("%w: %w", errNoMailHost, err)
+	}
+
+	// The deadline and the context bound every read and write of the conversation
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+
+		return MailboxUnknown, fmt.Errorf("%w: %w", errNoMailHost, err)
+	}
+
+	defer func() { _ = client.Close() }()
+
+	err = client.Hello(v.cfg.HeloName)
+	if err == nil {
+		err = client.Mail(v.cfg.MailFrom)
+	}
+
+	if err != nil {
+		return MailboxUnknown, fmt.Errorf("%w: %w", errNoMailHost, err)
+	}
+
+	status := classifyRecipient(client.Rcpt(recipient))
+
+	// Accepting the address only proves it exists when the host definitively rejects a made-up one;
+	// a greylisted or blocked probe leaves it open
+	if status == MailboxDeliverable {
+		switch classifyRecipient(client.Rcpt(probe)) {
+		case MailboxDeliverable:
+			status = MailboxCatchAll
+		case MailboxUndeliverable:
+			// The host tells addresses apart, so the acceptance stands
+		default:
+			status = MailboxUnknown
+		}
+	}
+
+	_ = client.Quit()
+
+	return status, nil
+}
+
+// classifyRecipient classifies the reply to RCPT TO. Only replies that name the mailbox as unknown
+// count as undeliverable; other rejections, such as policy blocks, leave the mailbox unknown.
+func classifyRecipient(err error) MailboxStatus {
+	if err == nil {
+		return MailboxDeliverable
+	}
+
+	var reply *textproto.Error
+	if !errors.As(err, &reply) {
+		return MailboxUnknown
+	}
+
+	// Enhanced status codes (RFC 3463) tell mailbox problems (x.1.x) from policy ones (x.7.x)
+	if enhanced, _, ok := strings.Cut(reply.Msg, " "); ok && strings.Count(enhanced, ".") == 2 {
+		if strings.HasPrefix(enhanced, "5.1.") {
+			return MailboxUndeliverable
+		}
+
+		return MailboxUnknown
+	}
+
+	switch reply.Code {
+	case smtpMailboxUnavailable, smtpUserNotLocal, smtpMailboxNameNotAllowed:
+		return MailboxUndeliverable
+	default:
+		return MailboxUnknown
+	}
+}
+
+// randomLocalPart returns a local part no one has, for probing catch-all domains.
+func randomLocalPart() string {
+	random := make([]byte, probeLocalPartBytes)
+	_, _ = rand.Read(random)
+
+	return "verify-" + hex.EncodeToString(random)
+}
@@ -0,0 +1,378 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unknownRecipients is how the fake SMTP server answers addresses it has no mailbox for.
+type unknownRecipients int
+
+const (
+	rejectUnknown unknownRecipients = iota
+	acceptUnknown
+	greylistUnknown
+)
+
+// fakeSMTPServer is a local SMTP server accepting the listed mailboxes and answering other addresses
+// as its policy says.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	mailboxes map[string]bool
+	unknown   unknownRecipients
+	// delay holds every conversation open, to observe how many run at once.
+	delay time.Duration
+
+	open, maxOpen atomic.Int64
+}
+
+func newFakeSMTPServer(
+	t *testing.T, unknown unknownRecipients, delay time.Duration, mailboxes ...string,
+) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &fakeSMTPServer{
+		listener:  listener,
+		mailboxes: make(map[string]bool),
+		unknown:   unknown,
+		delay:     delay,
+		open:      atomic.Int64{},
+		maxOpen:   atomic.Int64{},
+	}
+
+	for _, mailbox := range mailboxes {
+		server.mailboxes[mailbox] = true
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+// dial connects to the fake server whatever mail host is asked for.
+func (s *fakeSMTPServer) dial(ctx context.Context, network, _ string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	return dialer.DialContext(ctx, network, s.listener.Addr().String())
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	open := s.open.Add(1)
+	defer s.open.Add(-1)
+
+	for previous := s.maxOpen.Load(); open > previous && !s.maxOpen.CompareAndSwap(previous, open); {
+		previous = s.maxOpen.Load()
+	}
+
+	time.Sleep(s.delay)
+
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 fake.test ESMTP")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command, argument, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			_ = text.PrintfLine("250-fake.test\r\n250 8BITMIME")
+		case "MAIL":
+			_ = text.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			address := strings.Trim(strings.TrimPrefix(strings.ToUpper(argument), "TO:"), "<>")
+			switch {
+			case s.mailboxes[strings.ToLower(address)] || s.unknown == acceptUnknown:
+				_ = text.PrintfLine("250 2.1.5 OK")
+			case s.unknown == greylistUnknown:
+				_ = text.PrintfLine("451 4.7.1 Greylisted, try again later")
+			default:
+				_ = text.PrintfLine("550 5.1.1 No such user")
+			}
+		case "QUIT":
+			_ = text.PrintfLine("221 2.0.0 Bye")
+
+			return
+		default:
+			_ = text.PrintfLine("502 5.5.2 Command not implemented")
+		}
+	}
+}
+
+// smtpConfig returns a config talking to server.
+func smtpConfig(server *fakeSMTPServer) SMTPConfig {
+	cfg := DefaultSMTPConfig()
+	cfg.Timeout = time.Second
+	cfg.Dial = server.dial
+
+	return cfg
+}
+
+// mxVerification is the verification of a domain with a single mail host.
+func mxVerification() Verification {
+	return Verification{Domain: DomainMX, MailHosts: []string{"mx.example.net"}, Mailbox: MailboxUnverified}
+}
+
+func TestMailboxVerifierVerify(t *testing.T) {
+	t.Parallel()
+
+	mailboxes := newFakeSMTPServer(t, rejectUnknown, 0, "jane@example.net")
+	catchAll := newFakeSMTPServer(t, acceptUnknown, 0)
+	greylisting := newFakeSMTPServer(t, greylistUnknown, 0, "jane@example.net")
+
+	tests := []struct {
+		name     string
+		server   *fakeSMTPServer
+		email    string
+		domain   Verification
+		expected MailboxStatus
+	}{
+		{"existing mailbox", mailboxes, "jane@example.net", mxVerification(), MailboxDeliverable},
+		{"unknown mailbox", mailboxes, "john@example.net", mxVerification(), MailboxUndeliverable},
+		{"catch-all domain", catchAll, "jane@example.net", mxVerification(), MailboxCatchAll},
+		// Only a rejected probe shows that the mailbox was accepted for being known
+		{"greylisted probe", greylisting, "jane@example.net", mxVerification(), MailboxUnknown},
+		{"greylisted address", greylisting, "john@example.net", mxVerification(), MailboxUnknown},
+		{
+			"domain without mail", mailboxes, "jane@example.net",
+			Verification{Domain: DomainNoMail, MailHosts: nil, Mailbox: MailboxUnverified}, MailboxUndeliverable,
+		},
+		{
+			"unresolved domain", mailboxes, "jane@example.net",
+			Verification{Domain: DomainUnresolved, MailHosts: nil, Mailbox: MailboxUnverified}, MailboxUnknown,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			mailboxVerifier := newMailboxVerifier(smtpConfig(testCase.server))
+
+			if got := mailboxVerifier.verify(t.Context(), testCase.email, testCase.domain); got != testCase.expected {
+				t.Errorf("verify(%q) = %q, want %q", testCase.email, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestMailboxVerifierTimeout(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	// The silent server accepts connections but never greets
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				_, _ = io.Copy(io.Discard, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	cfg := DefaultSMTPConfig()
+	cfg.Timeout = 100 * time.Millisecond
+	cfg.Dial = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+
+		return dialer.DialContext(ctx, network, listener.Addr().String())
+	}
+
+	started := time.Now()
+
+	if got := newMailboxVerifier(cfg).verify(t.Context(), "jane@example.net", mxVerification()); got != MailboxUnknown {
+		t.Errorf("verify() = %q, want %q", got, MailboxUnknown)
+	}
+
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("verify() took %v, want it bound by the timeout", elapsed)
+	}
+}
+
+func TestMailboxVerifierLimitsConnectionsPerDomain(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeSMTPServer(t, rejectUnknown, 20*time.Millisecond, "jane@example.net")
+
+	cfg := smtpConfig(server)
+	cfg.MaxConnectionsPerDomain = 2
+	mailboxVerifier := newMailboxVerifier(cfg)
+
+	var group sync.WaitGroup
+
+	for range 6 {
+		group.Go(func() {
+			if got := mailboxVerifier.verify(t.Context(), "jane@example.net", mxVerification()); got != MailboxDeliverable {
+				t.Errorf("verify() = %q, want %q", got, MailboxDeliverable)
+			}
+		})
+	}
+
+	group.Wait()
+
+	if peak := server.maxOpen.Load(); peak > 2 {
+		t.Errorf("%d concurrent conversations with one domain, want at most 2", peak)
+	}
+}
+
+func TestMailboxVerifierForgetsIdleDomains(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeSMTPServer(t, rejectUnknown, 0, "jane@example.net")
+	mailboxVerifier := newMailboxVerifier(smtpConfig(server))
+
+	var group sync.WaitGroup
+
+	for _, email := range []string{"jane@example.net", "jane@example.org", "jane@example.com"} {
+		group.Go(func() { mailboxVerifier.verify(t.Context(), email, mxVerification()) })
+	}
+
+	group.Wait()
+
+	// A waiter that gives up must not keep the slots of its domain either
+	held := make([]func(), 0, defaultSMTPConnectionsPerDomain)
+
+	for range defaultSMTPConnectionsPerDomain {
+		release, err := mailboxVerifier.acquire(t.Context(), "example.net")
+		if err != nil {
+			t.Fatalf("acquire() error: %v", err)
+		}
+
+		held = append(held, release)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := mailboxVerifier.acquire(ctx, "example.net"); err == nil {
+		t.Fatal("acquire() of a full domain succeeded after cancellation")
+	}
+
+	for _, release := range held {
+		release()
+	}
+
+	mailboxVerifier.m.Lock()
+	defer mailboxVerifier.m.Unlock()
+
+	if len(mailboxVerifier.slots) != 0 {
+		t.Errorf("%d domains kept after their verifications finished, want none", len(mailboxVerifier.slots))
+	}
+}
+
+func TestHeloName(t *testing.T) {
+	t.Parallel()
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = fallbackHeloName
+	}
+
+	tests := []struct {
+		name     string
+		heloName string
+		mailFrom string
+		expected string
+	}{
+		{"configured", "mx.acme.org", "probe@verify.acme.org", "mx.acme.org"},
+		{"mail from domain", "", "probe@verify.acme.org", "verify.acme.org"},
+		{"host name", "", "", hostname},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultSMTPConfig()
+			cfg.HeloName = testCase.heloName
+			cfg.MailFrom = testCase.mailFrom
+
+			if got := heloName(cfg); got != testCase.expected {
+				t.Errorf("heloName() = %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestClassifyRecipient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		err      error
+		expected MailboxStatus
+	}{
+		{"accepted", nil, MailboxDeliverable},
+		{"unknown user", &textproto.Error{Code: 550, Msg: "5.1.1 No such user"}, MailboxUndeliverable},
+		{"policy block", &textproto.Error{Code: 550, Msg: "5.7.1 Blocked by policy"}, MailboxUnknown},
+		{"plain rejection", &textproto.Error{Code: 553, Msg: "mailbox name not allowed"}, MailboxUndeliverable},
+		{"greylisted", &textproto.Error{Code: 451, Msg: "4.7.1 Try again later"}, MailboxUnknown},
+		{"connection lost", net.ErrClosed, MailboxUnknown},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := classifyRecipient(testCase.err); got != testCase.expected {
+				t.Errorf("classifyRecipient(%v) = %q, want %q", testCase.err, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestVerifierVerifiesMailboxes(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeSMTPServer(t, rejectUnknown, 0, "info@mail.test.io")
+
+	cfg := verificationConfig(newFakeResolver())
+	cfg.VerifyMailboxes = true
+	cfg.SMTP = smtpConfig(server)
+
+	got := newVerifier(cfg).verify(t.Context(), "info@mail.test.io")
+	if got.Domain != DomainMX || got.Mailbox != MailboxDeliverable {
+		t.Errorf("verify() = %+v, want a deliverable mailbox at an MX domain", got)
+	}
+}
@@ -0,0 +1,78 @@
+package emailscraper
+
+import (
+	"context"
+	"sync"
+)
+
+// streamBufferSize is the number of findings a stream buffers before the crawl waits for the consumer.
+const streamBufferSize = 64
+
+// Stream delivers findings while a scrape is still running.
+type Stream struct {
+	findings chan Finding
+	done     chan struct{}
+
+	m      sync.Mutex
+	closed bool
+
+	result Result
+	err    error
+}
+
+// ScrapeStream starts scraping url in the background and delivers each email as soon as it is found.
+// The caller must drain Findings or cancel ctx, otherwise the crawl stalls once the buffer is full.
+func (s *Scraper) ScrapeStream(ctx context.Context, url string) *Stream {
+	//nolint:exhaustruct // result and err are set by finish
+	stream := &Stream{
+		findings: make(chan Finding, streamBufferSize),
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		result, err := s.scrape(ctx, url, func(finding Finding) {
+			stream.emit(ctx, finding)
+		})
+
+		stream.finish(result, err)
+	}()
+
+	return stream
+}
+
+// Findings returns the channel findings are delivered on. It is closed when the scrape ends.
+func (st *Stream) Findings() <-chan Finding {
+	return st.findings
+}
+
+// Wait blocks until the scrape ends and returns its final result.
+func (st *Stream) Wait() (Result, error) {
+	<-st.done
+
+	return st.result, st.err
+}
+
+func (st *Stream) emit(ctx context.Context, finding Finding) {
+	st.m.Lock()
+	defer st.m.Unlock()
+
+	if st.closed {
+		return
+	}
+
+	select {
+	case st.findings <- finding:
+	case <-ctx.Done():
+	}
+}
+
+func (st *Stream) finish(result Result, err error) {
+	st.m.Lock()
+	st.closed = true
+	close(st.findings)
+	st.m.Unlock()
+
+	st.result, st.err = result, err
+
+	close(st.done)
+}
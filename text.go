@@ -0,0 +1,80 @@
+package emailscraper
+
+import (
+	"github.com/chromedp/chromedp"
+)
+
+// visibleTextScript returns the text a visitor sees: document.body.innerText followed by a walk of the DOM
+// in document order that also includes CSS ::before/::after content, open shadow roots and same-origin
+// iframes. Inline elements are joined without separators, so addresses split across spans come out whole.
+const visibleTextScript = `(() => {
+	const skipped = new Set(["SCRIPT", "STYLE", "NOSCRIPT", "TEMPLATE"]);
+	const parts = [];
+
+	const pseudoContent = (element, pseudo) => {
+		const content = getComputedStyle(element, pseudo).content;
+		const quoted = /^"((?:[^"\\]|\\.)*)"$/s.exec(content || "");
+
+		return quoted ? quoted[1].replace(/\\(.)/g, "$1") : "";
+	};
+
+	const walk = (node) => {
+		if (node.nodeType === Node.TEXT_NODE) {
+			parts.push(node.textContent);
+
+			return;
+		}
+
+		if (node.nodeType === Node.DOCUMENT_FRAGMENT_NODE) {
+			node.childNodes.forEach(walk);
+
+			return;
+		}
+
+		if (node.nodeType !== Node.ELEMENT_NODE || skipped.has(node.tagName)) {
+			return;
+		}
+
+		const style = getComputedStyle(node);
+		if (style.display === "none" || style.visibility === "hidden") {
+			return;
+		}
+
+		parts.push(pseudoContent(node, "::before"));
+
+		if (node.shadowRoot) {
+			walk(node.shadowRoot);
+		}
+
+		if (node.tagName === "IFRAME") {
+			try {
+				if (node.contentDocument && node.contentDocument.body) {
+					walk(node.contentDocument.body);
+				}
+			} catch (error) {
+				// cross-origin frames are not accessible
+			}
+		}
+
+		node.childNodes.forEach(walk);
+
+		parts.push(pseudoContent(node, "::after"));
+
+		if (!style.display.startsWith("inline")) {
+			parts.push("\n");
+		}
+	};
+
+	if (!document.body) {
+		return "";
+	}
+
+	walk(document.body);
+
+	return document.body.innerText + "\n" + parts.join("");
+})()`
+
+// visibleText returns an action that stores the rendered, visible text of the page in text.
+func visibleText(text *string) chromedp.Action {
+	return chromedp.Evaluate(visibleTextScript, text)
+}
@@ -0,0 +1,125 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dop251/goja"
+)
+
+// fakeDocument is a minimal DOM for running visibleTextScript outside a browser. Elements take their
+// computed style and ::before/::after content from plain properties.
+const fakeDocument = `
+	const Node = {ELEMENT_NODE: 1, TEXT_NODE: 3, DOCUMENT_FRAGMENT_NODE: 11};
+	const shown = {display: "block", visibility: "visible"};
+
+	const text = (content) => ({nodeType: Node.TEXT_NODE, textContent: content});
+	const element = (tagName, childNodes, extra) => Object.assign(
+		{nodeType: Node.ELEMENT_NODE, tagName, childNodes, style: shown, shadowRoot: null}, extra);
+	const inline = (childNodes, extra) => element("SPAN", childNodes,
+		Object.assign({style: {display: "inline", visibility: "visible"}}, extra));
+
+	const getComputedStyle = (node, pseudo) => {
+		if (pseudo === "::before") {
+			return {content: node.before || "none"};
+		}
+
+		if (pseudo === "::after") {
+			return {content: node.after || "none"};
+		}
+
+		return node.style;
+	};
+
+	const crossOrigin = element("IFRAME", []);
+	Object.defineProperty(crossOrigin, "contentDocument", {get: () => { throw new Error("blocked"); }});
+
+	const document = {
+		body: Object.assign(element("BODY", [
+			element("P", [inline([text("split")]), inline([text("@")]), inline([text("example.com")])]),
+			element("P", [inline([text("example.org")], {before: '"css\\@"'})]),
+			element("P", [text("hidden@example.com")], {style: {display: "none", visibility: "visible"}}),
+			element("P", [text("invisible@example.com")], {style: {display: "block", visibility: "hidden"}}),
+			element("SCRIPT", [text("var code = 'script@example.com';")]),
+			element("DIV", [], {shadowRoot: {nodeType: Node.DOCUMENT_FRAGMENT_NODE, childNodes: [
+				text("shadow@example.net"),
+			]}}),
+			element("IFRAME", [], {contentDocument: {body: element("BODY", [text("frame@example.io")])}}),
+			crossOrigin,
+		]), {innerText: "Write to inner@example.com"}),
+	};
+`
+
+func TestVisibleTextScript(t *testing.T) {
+	t.Parallel()
+
+	vm := goja.New()
+
+	if _, err := vm.RunString(fakeDocument); err != nil {
+		t.Fatalf("setting up the document: %v", err)
+	}
+
+	value, err := vm.RunString(visibleTextScript)
+	if err != nil {
+		t.Fatalf("running the script: %v", err)
+	}
+
+	visible := value.String()
+
+	// innerText comes first, then the walk that also sees generated content, shadow roots and frames
+	if !strings.HasPrefix(visible, "Write to inner@example.com\n") {
+		t.Errorf("visible text does not start with innerText: %q", visible)
+	}
+
+	set := newEmails(nil, nil, nil)
+	set.parseEmails([]byte(visible), origin{url: "https://example.com/", depth: 0})
+
+	got := set.toSlice()
+	slices.Sort(got)
+
+	expected := []string{
+		"css@example.org", "frame@example.io", "inner@example.com", "shadow@example.net", "split@example.com",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("emails in the visible text = %v, want %v (text %q)", got, expected, visible)
+	}
+}
+
+func TestParseRendered(t *testing.T) {
+	t.Parallel()
+
+	set := newEmails(nil, nil, nil)
+	set.parseRendered(renderOutput{
+		text:     "Contact text@example.com or both@example.com",
+		revealed: []string{"<p>revealed@example.com</p>", "<p>both@example.com</p>"},
+		captured: []capturedResponse{
+			{url: "https://example.com/api/team", body: []byte(`{"email":"api@example.com"}`)},
+			{url: "https://example.com/api/contact", body: []byte(`{"email":"both@example.com"}`)},
+		},
+		blocked: nil,
+	}, origin{url: "https://example.com/contact", depth: 2})
+
+	expected := map[string][]string{
+		"text@example.com":     {"https://example.com/contact"},
+		"revealed@example.com": {"https://example.com/contact"},
+		"api@example.com":      {"https://example.com/api/team"},
+		"both@example.com":     {"https://example.com/contact", "https://example.com/api/contact"},
+	}
+
+	findings := set.findings()
+	if len(findings) != len(expected) {
+		t.Fatalf("parseRendered() found %d emails, want %d: %v", len(findings), len(expected), findings)
+	}
+
+	for _, finding := range findings {
+		if !slices.Equal(finding.Sources, expected[finding.Email]) {
+			t.Errorf("%s sources = %v, want %v", finding.Email, finding.Sources, expected[finding.Email])
+		}
+
+		if finding.Depth != 2 {
+			t.Errorf("%s depth = %d, want the depth of the page", finding.Email, finding.Depth)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package emailscraper
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	// maxAddressLength is the maximum length of an address that fits an SMTP path (RFC 5321 section 4.5.3.1.3).
+	maxAddressLength = 254
+	// maxLocalPartLength is the maximum length of the local part of an address (RFC 5321).
+	maxLocalPartLength = 64
+	// maxDomainLength is the maximum length of a domain name in its ASCII form (RFC 1035).
+	maxDomainLength = 253
+	// maxDomainLabelLength is the maximum length of a domain label (RFC 1035).
+	maxDomainLabelLength = 63
+
+	// atextSymbols are the symbols allowed in a dot-atom local part besides letters and digits (RFC 5322).
+	atextSymbols = "!#$%&'*+-/=?^_`{|}~"
+)
+
+// ValidationMode selects how strictly extracted addresses are validated.
+type ValidationMode int
+
+const (
+	// ValidationLenient enforces the length limits of RFC 5321 and the structure of the local part and
+	// domain, but tolerates characters that are common on the web though not standard, such as
+	// underscores in domain labels.
+	ValidationLenient ValidationMode = iota
+	// ValidationStrict additionally requires a dot-atom local part (RFC 5322, RFC 6531) and a domain
+	// that is a valid host name under IDNA2008.
+	ValidationStrict
+)
+
+var (
+	errNoSeparator        = errors.New("no @ separator")
+	errAddressLength      = errors.New("address longer than 254 octets")
+	errLocalPartLength    = errors.New("local part empty or longer than 64 octets")
+	errLocalPartDots      = errors.New("local part starts or ends with a dot or has consecutive dots")
+	errLocalPartCharacter = errors.New("character not allowed in the local part")
+	errDomainLength       = errors.New("domain longer than 253 octets")
+	errDomainLabels       = errors.New("domain has less than two labels")
+	errDomainLabelLength  = errors.New("domain label empty or longer than 63 octets")
+	errDomainLabelHyphen  = errors.New("domain label starts or ends with a hyphen")
+	errDomainCharacter    = errors.New("character not allowed in the domain")
+	errDomainIDNA         = errors.New("domain is not a valid host name")
+	errUnknownTLD         = errors.New("unknown top-level domain")
+)
+
+// validateAddress parses email into its local part and domain and checks them against the limits of
+// RFC 5321 and RFC 5322. The error tells why an address was rejected.
+func validateAddress(email string, mode ValidationMode) error {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return errNoSeparator
+	}
+
+	if len(email) > maxAddressLength {
+		return errAddressLength
+	}
+
+	err := validateLocalPart(email[:at], mode)
+	if err != nil {
+		return err
+	}
+
+	return validateDomain(email[at+1:], mode)
+}
+
+// validateLocalPart checks an unquoted local part: dot-separated atoms of allowed characters.
+func validateLocalPart(local string, mode ValidationMode) error {
+	if local == "" || len(local) > maxLocalPartLength {
+		return errLocalPartLength
+	}
+
+	if local[0] == '.' || local[len(local)-1] == '.' || strings.Contains(local, "..") {
+		return errLocalPartDots
+	}
+
+	for _, char := range local {
+		if !localPartCharacter(char, mode) {
+			return fmt.Errorf("%w: %q", errLocalPartCharacter, char)
+		}
+	}
+
+	return nil
+}
+
+// localPartCharacter reports whether char may appear in an unquoted local part. Non-ASCII characters
+// are allowed by RFC 6531; the lenient mode only rejects separators, spaces and control characters.
+func localPartCharacter(char rune, mode ValidationMode) bool {
+	switch {
+	case char == '@' || unicode.IsSpace(char) || unicode.IsControl(char):
+		return false
+	case mode == ValidationLenient || char > unicode.MaxASCII:
+		return true
+	default:
+		return char == '.' || unicode.IsLetter(char) || unicode.IsDigit(char) || strings.ContainsRune(atextSymbols, char)
+	}
+}
+
+// validateDomain checks the labels of a domain and its top-level domain. Lengths are measured on the
+// ASCII form of internationalized labels, as that is what travels over SMTP.
+func validateDomain(domain string, mode ValidationMode) error {
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		if mode == ValidationStrict {
+			return fmt.Errorf("%w: %w", errDomainIDNA, err)
+		}
+
+		// Lenient validation measures labels IDNA rejects, such as ones with underscores, as they are
+		ascii, err = idna.Punycode.ToASCII(domain)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errDomainIDNA, err)
+		}
+	}
+
+	if len(ascii) > maxDomainLength {
+		return errDomainLength
+	}
+
+	labels := strings.Split(ascii, ".")
+	if len(labels) < minEmailDomainParts {
+		return errDomainLabels
+	}
+
+	for _, label := range labels {
+		err := validateDomainLabel(label)
+		if err != nil {
+			return err
+		}
+	}
+
+	ending := labels[len(labels)-1]
+
+	// check if TLD name actually exists and is not some image ending
+	if len(ending) < minTLDLength || !validTLD(ending) {
+		return fmt.Errorf("%w: %q", errUnknownTLD, ending)
+	}
+
+	if _, err := strconv.Atoi(ending); err == nil {
+		return fmt.Errorf("%w: %q", errUnknownTLD, ending)
+	}
+
+	return nil
+}
+
+// validateDomainLabel checks a label in ASCII form: letters, digits and inner hyphens; underscores are
+// only left for lenient validation, strict validation rejects them when converting to ASCII.
+func validateDomainLabel(label string) error {
+	if label == "" || len(label) > maxDomainLabelLength {
+		return errDomainLabelLength
+	}
+
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Errorf("%w: %q", errDomainLabelHyphen, label)
+	}
+
+	for _, char := range label {
+		if char > unicode.MaxASCII || !(char == '-' || char == '_' || unicode.IsLetter(char) || unicode.IsDigit(char)) {
+			return fmt.Errorf("%w: %q", errDomainCharacter, char)
+		}
+	}
+
+	return nil
+}
+
+// Check if email looks valid.
+func isValidEmail(email string) bool {
+	return validateAddress(email, ValidationLenient) == nil
+}
@@ -0,0 +1,110 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateAddress(t *testing.T) {
+	t.Parallel()
+
+	longLabel := strings.Repeat("a", maxDomainLabelLength+1)
+	longLocalPart := strings.Repeat("a", maxLocalPartLength+1)
+	longAddress := strings.Repeat("a", 60) + "@" + strings.Repeat(longLabel[:60]+".", 4) + "com"
+
+	tests := []struct {
+		name    string
+		email   string
+		lenient error
+		strict  error
+	}{
+		{"simple", "info@example.com", nil, nil},
+		{"plus tag", "jane+news@example.com", nil, nil},
+		{"atext symbols", "o'brien!#$%&*/=?^_`{|}~@example.com", nil, nil},
+		{"unicode local part", "müller@bücher.de", nil, nil},
+		{"punycode domain", "info@xn--bcher-kva.de", nil, nil},
+		{"no separator", "example.com", errNoSeparator, errNoSeparator},
+		{"leading dot", ".info@example.com", errLocalPartDots, errLocalPartDots},
+		{"trailing dot", "info.@example.com", errLocalPartDots, errLocalPartDots},
+		{"consecutive dots", "first..last@example.com", errLocalPartDots, errLocalPartDots},
+		{"long local part", longLocalPart + "@example.com", errLocalPartLength, errLocalPartLength},
+		{"empty local part", "@example.com", errLocalPartLength, errLocalPartLength},
+		{"space in local part", "jo hn@example.com", errLocalPartCharacter, errLocalPartCharacter},
+		{"special in local part", "a,b@example.com", nil, errLocalPartCharacter},
+		{"hyphen-edged label", "info@-example.com", errDomainLabelHyphen, errDomainIDNA},
+		{"empty label", "info@example..com", errDomainLabelLength, errDomainLabelLength},
+		{"long label", "info@" + longLabel + ".com", errDomainLabelLength, errDomainLabelLength},
+		{"underscore in label", "info@my_host.example.com", nil, errDomainIDNA},
+		{"single label", "info@localhost", errDomainLabels, errDomainLabels},
+		{"image extension", "logo@2x.png", errUnknownTLD, errUnknownTLD},
+		{"numeric TLD", "info@192.168.0.1", errUnknownTLD, errUnknownTLD},
+		{"long address", longAddress, errAddressLength, errAddressLength},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := validateAddress(testCase.email, ValidationLenient); !errors.Is(err, testCase.lenient) {
+				t.Errorf("lenient validateAddress(%q) = %v, want %v", testCase.email, err, testCase.lenient)
+			}
+
+			if err := validateAddress(testCase.email, ValidationStrict); !errors.Is(err, testCase.strict) {
+				t.Errorf("strict validateAddress(%q) = %v, want %v", testCase.email, err, testCase.strict)
+			}
+		})
+	}
+}
+
+func TestParseEmailsTrimsJoiningPunctuation(t *testing.T) {
+	t.Parallel()
+
+	emailSet := &emails{} //nolint:exhaustruct // zero value is valid
+	body := `var to = 'help@example.com'; see ...sales@example.com or o'brien@example.ie`
+	emailSet.parseEmails([]byte(body), testOrigin())
+
+	got := emailSet.toSlice()
+	slices.Sort(got)
+
+	expected := []string{"help@example.com", "o'brien@example.ie", "sales@example.com"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("parseEmails() = %v, want %v", got, expected)
+	}
+}
+
+func TestParseEmailsKeepsAtextLocalParts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"equals sign", "write to bill=ops@acme.org today", []string{"bill=ops@acme.org"}},
+		{"exclamation mark", "jane!doe@acme.org", []string{"jane!doe@acme.org"}},
+		{"hash", "<p>a#b@acme.org</p>", []string{"a#b@acme.org"}},
+		{"other symbols", "x$y&z*w^v{u}t|s~r@acme.org", []string{"x$y&z*w^v{u}t|s~r@acme.org"}},
+		{"url query", `<a href="/contact?subject=hi&email=info@acme.org">`, []string{"info@acme.org"}},
+		{"url path", "https://acme.org/people/jane@acme.org", []string{"jane@acme.org"}},
+		{"markup", "**sales@acme.org** or `help@acme.org`", []string{"help@acme.org", "sales@acme.org"}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			emailSet := &emails{} //nolint:exhaustruct // zero value is valid
+			emailSet.parseEmails([]byte(testCase.body), testOrigin())
+
+			got := emailSet.toSlice()
+			slices.Sort(got)
+
+			if !slices.Equal(got, testCase.expected) {
+				t.Errorf("parseEmails(%q) = %v, want %v", testCase.body, got, testCase.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package emailscraper
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	// defaultVerificationTimeout is the default time the DNS lookups of a domain may take.
+	defaultVerificationTimeout = 5 * time.Second
+	// defaultVerificationCacheTTL is the default time the result of a domain lookup is reused.
+	defaultVerificationCacheTTL = time.Hour
+	// defaultVerificationCacheSize is the default number of domains whose lookups are kept.
+	defaultVerificationCacheSize = 10000
+	// verificationConcurrency is the number of addresses a scraper verifies at once.
+	verificationConcurrency = 8
+)
+
+// Resolver looks up the DNS records domain verification needs. *net.Resolver implements it, so a
+// resolver dialing a local DNS stub or a company resolver can be plugged in.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// VerificationConfig configures the domain verification enabled by VerifyDomains (and VerifyMailboxes).
+type VerificationConfig struct {
+	// Resolver resolves the mail hosts of domains; nil uses net.DefaultResolver.
+	Resolver Resolver
+	// Timeout bounds the DNS lookups of a single domain.
+	Timeout time.Duration
+	// CacheTTL is how long the result of a domain lookup is reused; zero uses the default of an hour.
+	CacheTTL time.Duration
+	// CacheSize is the number of domains whose lookups are kept; zero uses the default.
+	CacheSize int
+}
+
+// DefaultVerificationConfig returns the default verification config, resolving with the system resolver.
+func DefaultVerificationConfig() VerificationConfig {
+	return VerificationConfig{
+		Resolver:  net.DefaultResolver,
+		Timeout:   defaultVerificationTimeout,
+		CacheTTL:  defaultVerificationCacheTTL,
+		CacheSize: defaultVerificationCacheSize,
+	}
+}
+
+// DomainStatus tells whether the domain of an address accepts mail.
+type DomainStatus string
+
+const (
+	// DomainUnverified marks addresses whose domain was not verified.
+	DomainUnverified DomainStatus = ""
+	// DomainMX marks domains with MX records.
+	DomainMX DomainStatus = "mx"
+	// DomainAddressRecord marks domains without MX records that resolve to an address, which mail is
+	// delivered to directly (RFC 5321 section 5.1).
+	DomainAddressRecord DomainStatus = "address"
+	// DomainNoMail marks domains that do not exist, have no address to deliver to or publish a null MX
+	// record (RFC 7505).
+	DomainNoMail DomainStatus = "no-mail"
+	// DomainUnresolved marks domains whose lookup failed, e.g. timed out; their status is unknown.
+	DomainUnresolved DomainStatus = "unresolved"
+)
+
+// Verification is the outcome of verifying an address.
+type Verification struct {
+	// Domain tells whether the domain of the address accepts mail.
+	Domain DomainStatus
+	// MailHosts are the hosts accepting mail for the domain, most preferred first.
+	MailHosts []string
+	// Mailbox tells whether the mail hosts accept mail for the address, when VerifyMailboxes is set.
+	Mailbox MailboxStatus
+}
+
+// clone returns a copy of the verification that shares no slices with it.
+func (v Verification) clone() Verification {
+	v.MailHosts = slices.Clone(v.MailHosts)
+
+	return v
+}
+
+// domainLookup is the lookup of a single domain, shared by every address at the domain.
+type domainLookup struct {
+	done         chan struct{}
+	verification Verification
+	// expires is when the lookup is repeated; it is set once the lookup is done.
+	expires time.Time
+}
+
+// expired reports whether a finished lookup is too old to be reused. The caller must hold the lock
+// of the verifier.
+func (l *domainLookup) expired(now time.Time) bool {
+	return !l.expires.IsZero() && now.After(l.expires)
+}
+
+// verifier resolves the mail hosts of domains, caching them for a while, and optionally asks them
+// about mailboxes.
+type verifier struct {
+	resolver  Resolver
+	timeout   time.Duration
+	cacheTTL  time.Duration
+	cacheSize int
+	// mailboxes is nil unless VerifyMailboxes is set.
+	mailboxes *mailboxVerifier
+	// slots limits the addresses verified at once across all scrapes of the scraper.
+	slots chan struct{}
+
+	m       sync.Mutex
+	domains map[string]*domainLookup
+}
+
+// newVerifier prepares verification as configured.
+func newVerifier(cfg Config) *verifier {
+	resolver := cfg.Verification.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	var mailboxes *mailboxVerifier
+	if cfg.VerifyMailboxes {
+		mailboxes = newMailboxVerifier(cfg.SMTP)
+	}
+
+	cacheTTL := cfg.Verification.CacheTTL
+	if cacheTTL <= 0 {
+		cacheTTL = defaultVerificationCacheTTL
+	}
+
+	cacheSize := cfg.Verification.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = defaultVerificationCacheSize
+	}
+
+	return &verifier{
+		resolver:  resolver,
+		timeout:   cfg.Verification.Timeout,
+		cacheTTL:  cacheTTL,
+		cacheSize: cacheSize,
+		mailboxes: mailboxes,
+		slots:     make(chan struct{}, verificationConcurrency),
+		m:         sync.Mutex{},
+		domains:   nil,
+	}
+}
+
+// verify verifies the domain of email and, when enabled, its mailbox. It waits for one of the
+// verification slots of the scraper.
+func (v *verifier) verify(ctx context.Context, email string) Verification {
+	select {
+	case v.slots <- struct{}{}:
+		defer func() { <-v.slots }()
+	case <-ctx.Done():
+		verification := Verification{Domain: DomainUnresolved, MailHosts: nil, Mailbox: MailboxUnverified}
+		if v.mailboxes != nil {
+			verification.Mailbox = MailboxUnknown
+		}
+
+		return verification
+	}
+
+	verification := v.domain(ctx, email[strings.LastIndexByte(email, '@')+1:])
+
+	if v.mailboxes != nil {
+		verification.Mailbox = v.mailboxes.verify(ctx, email, verification)
+	}
+
+	return verification
+}
+
+// domain verifies a domain. Concurrent calls for one domain share a single lookup, and definite
+// results are cached until they expire; failed lookups are retried by later calls.
+func (v *verifier) domain(ctx context.Context, domain string) Verification {
+	if ascii, err := idna.Lookup.ToASCII(domain); err == nil {
+		domain = ascii
+	}
+
+	v.m.Lock()
+
+	if lookup, ok := v.domains[domain]; ok && !lookup.expired(time.Now()) {
+		v.m.Unlock()
+
+		select {
+		case <-lookup.done:
+			return lookup.verification.clone()
+		case <-ctx.Done():
+			return Verification{Domain: DomainUnresolved, MailHosts: nil, Mailbox: MailboxUnverified}
+		}
+	}
+
+	if v.domains == nil {
+		v.domains = make(map[string]*domainLookup)
+	}
+
+	v.evict()
+
+	lookup := &domainLookup{
+		done:         make(chan struct{}),
+		verification: Verification{Domain: DomainUnverified, MailHosts: nil, Mailbox: MailboxUnverified},
+		expires:      time.Time{},
+	}
+	v.domains[domain] = lookup
+
+	v.m.Unlock()
+
+	verification := v.resolve(ctx, domain)
+
+	v.m.Lock()
+
+	lookup.verification = verification
+	lookup.expires = time.Now().Add(v.cacheTTL)
+
+	if verification.Domain == DomainUnresolved && v.domains[domain] == lookup {
+		delete(v.domains, domain)
+	}
+
+	v.m.Unlock()
+
+	close(lookup.done)
+
+	return lookup.verification.clone()
+}
+
+// evict makes room in the cache for one more domain, dropping expired lookups and, when the cache is
+// still full, arbitrary finished ones. The caller must hold v.m.
+func (v *verifier) evict() {
+	if len(v.domains) < v.cacheSize {
+		return
+	}
+
+	now := time.Now()
+
+	maps.DeleteFunc(v.domains, func(_ string, lookup *domainLookup) bool {
+		return lookup.expired(now)
+	})
+
+	for domain, lookup := range v.domains {
+		if len(v.domains) < v.cacheSize {
+			return
+		}
+
+		// Lookups in progress are shared by waiting callers
+		if !lookup.expires.IsZero() {
+			delete(v.domains, domain)
+		}
+	}
+}
+
+// resolve looks up the MX records of domain, falling back to its addresses when it has none.
+func (v *verifier) resolve(ctx context.Context, domain string) Verification {
+	if v.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, v.timeout)
+		defer cancel()
+	}
+
+	records, err := v.resolver.LookupMX(ctx, domain)
+	if err != nil && !notFound(err) {
+		return Verification{Domain: DomainUnresolved, MailHosts: nil, Mailbox: MailboxUnverified}
+	}
+
+	// Resolvers other than the system one may return records in any order
+	records = slices.Clone(records)
+	slices.SortStableFunc(records, func(first, second *net.MX) int {
+		return cmp.Compare(first.Pref, second.Pref)
+	})
+
+	hosts := make([]string, 0, len(records))
+	for _, record := range records {
+		hosts = append(hosts, strings.TrimSuffix(record.Host, "."))
+	}
+
+	switch {
+	case len(hosts) == 1 && hosts[0] == "":
+		// A null MX record declares that the domain accepts no mail
+		return Verification{Domain: DomainNoMail, MailHosts: nil, Mailbox: MailboxUnverified}
+	case len(hosts) > 0:
+		return Verification{Domain: DomainMX, MailHosts: hosts, Mailbox: MailboxUnverified}
+	}
+
+	addresses, err := v.resolver.LookupIPAddr(ctx, domain)
+
+	switch {
+	case err != nil && !notFound(err):
+		return Verification{Domain: DomainUnresolved, MailHosts: nil, Mailbox: MailboxUnverified}
+	case len(addresses) == 0:
+		return Verification{Domain: DomainNoMail, MailHosts: nil, Mailbox: MailboxUnverified}
+	default:
+		return Verification{Domain: DomainAddressRecord, MailHosts: []string{domain}, Mailbox: MailboxUnverified}
+	}
+}
+
+// notFound reports whether a lookup failed because the records do not exist.
+func notFound(err error) bool {
+	var dnsErr *net.DNSError
+
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
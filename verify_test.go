@@ -0,0 +1,268 @@
+//nolint:testpackage // need access to internal functions
+package emailscraper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var errServerFailure = errors.New("server misbehaving")
+
+// fakeResolver answers lookups from fixed records and counts them.
+type fakeResolver struct {
+	mx      map[string][]*net.MX
+	ips     map[string][]net.IPAddr
+	failing map[string]bool
+	lookups atomic.Int64
+}
+
+func (r *fakeResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	r.lookups.Add(1)
+
+	if r.failing[name] {
+		//nolint:exhaustruct // test error
+		return nil, &net.DNSError{Err: errServerFailure.Error(), Name: name, IsTemporary: true}
+	}
+
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true} //nolint:exhaustruct // test error
+}
+
+func (r *fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	if addresses, ok := r.ips[host]; ok {
+		return addresses, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true} //nolint:exhaustruct // test error
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{
+		mx: map[string][]*net.MX{
+			"mail.test.io":   {{Host: "mx1.mail.test.io.", Pref: 10}, {Host: "mx2.mail.test.io.", Pref: 20}},
+			"nullmx.test.io": {{Host: ".", Pref: 0}},
+			"unsorted.test.io": {
+				{Host: "backup.unsorted.test.io.", Pref: 30}, {Host: "mx.unsorted.test.io.", Pref: 5},
+				{Host: "second.unsorted.test.io.", Pref: 10},
+			},
+		},
+		ips: map[string][]net.IPAddr{
+			"direct.test.io": {{IP: net.IPv4(192, 0, 2, 1), Zone: ""}},
+		},
+		failing: map[string]bool{"broken.test.io": true},
+		lookups: atomic.Int64{},
+	}
+}
+
+// verificationConfig returns a config verifying domains with resolver.
+func verificationConfig(resolver Resolver) Config {
+	cfg := DefaultConfig()
+	cfg.VerifyDomains = true
+	cfg.Verification.Resolver = resolver
+
+	return cfg
+}
+
+func TestVerifierVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		email     string
+		wantState DomainStatus
+		wantHosts []string
+	}{
+		{"mx records", "info@mail.test.io", DomainMX, []string{"mx1.mail.test.io", "mx2.mail.test.io"}},
+		{
+			"mx records by preference", "info@unsorted.test.io", DomainMX,
+			[]string{"mx.unsorted.test.io", "second.unsorted.test.io", "backup.unsorted.test.io"},
+		},
+		{"address fallback", "info@direct.test.io", DomainAddressRecord, []string{"direct.test.io"}},
+		{"null mx", "info@nullmx.test.io", DomainNoMail, nil},
+		{"no such domain", "info@missing.test.io", DomainNoMail, nil},
+		{"lookup failure", "info@broken.test.io", DomainUnresolved, nil},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			domains := newVerifier(verificationConfig(newFakeResolver()))
+
+			got := domains.verify(t.Context(), testCase.email)
+			if got.Domain != testCase.wantState || !slices.Equal(got.MailHosts, testCase.wantHosts) {
+				t.Errorf("verify(%q) = %+v, want %s %v", testCase.email, got, testCase.wantState, testCase.wantHosts)
+			}
+		})
+	}
+}
+
+func TestVerifierCachesDomains(t *testing.T) {
+	t.Parallel()
+
+	resolver := newFakeResolver()
+	domains := newVerifier(verificationConfig(resolver))
+
+	var group sync.WaitGroup
+
+	for _, email := range []string{"a@mail.test.io", "b@mail.test.io", "c@MAIL.test.io", "d@mail.test.io"} {
+		group.Go(func() {
+			if got := domains.verify(t.Context(), email); got.Domain != DomainMX {
+				t.Errorf("verify(%q) = %+v, want MX", email, got)
+			}
+		})
+	}
+
+	group.Wait()
+
+	if lookups := resolver.lookups.Load(); lookups != 1 {
+		t.Errorf("%d MX lookups for one domain, want 1", lookups)
+	}
+
+	domains.verify(t.Context(), "a@broken.test.io")
+	domains.verify(t.Context(), "b@broken.test.io")
+
+	if lookups := resolver.lookups.Load(); lookups != 3 {
+		t.Errorf("%d MX lookups after two failures, want failed lookups retried", lookups)
+	}
+}
+
+func TestVerifierCacheExpires(t *testing.T) {
+	t.Parallel()
+
+	resolver := newFakeResolver()
+	cfg := verificationConfig(resolver)
+	cfg.Verification.CacheTTL = time.Millisecond
+	domains := newVerifier(cfg)
+
+	domains.verify(t.Context(), "a@mail.test.io")
+	time.Sleep(5 * time.Millisecond)
+	domains.verify(t.Context(), "b@mail.test.io")
+
+	if lookups := resolver.lookups.Load(); lookups != 2 {
+		t.Errorf("%d MX lookups, want expired lookups repeated", lookups)
+	}
+}
+
+func TestVerifierCacheSize(t *testing.T) {
+	t.Parallel()
+
+	cfg := verificationConfig(newFakeResolver())
+	cfg.Verification.CacheSize = 2
+	domains := newVerifier(cfg)
+
+	for _, email := range []string{"a@mail.test.io", "a@direct.test.io", "a@nullmx.test.io", "a@missing.test.io"} {
+		domains.verify(t.Context(), email)
+	}
+
+	domains.m.Lock()
+	defer domains.m.Unlock()
+
+	if cached := len(domains.domains); cached > 2 {
+		t.Errorf("%d domains cached, want at most 2", cached)
+	}
+}
+
+func TestVerifierWithDNSStub(t *testing.T) {
+	t.Parallel()
+
+	stub := newDNSStub(t)
+	resolver := &net.Resolver{ //nolint:exhaustruct // only the dialer is replaced
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+
+			return dialer.DialContext(ctx, network, stub)
+		},
+	}
+
+	domains := newVerifier(verificationConfig(resolver))
+
+	got := domains.verify(t.Context(), "info@stub.test")
+	if got.Domain != DomainMX || !slices.Equal(got.MailHosts, []string{"mx.stub.test"}) {
+		t.Errorf("verify() = %+v, want mx.stub.test", got)
+	}
+}
+
+// newDNSStub serves a local DNS server answering every MX query with mx.stub.test and returns its address.
+func newDNSStub(t *testing.T) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error: %v", err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 512)
+
+		for {
+			size, from, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+
+			if answer, ok := answerMX(buffer[:size]); ok {
+				_, _ = conn.WriteTo(answer, from)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// answerMX builds the response to a DNS query, with a single MX record for MX questions.
+func answerMX(query []byte) ([]byte, bool) {
+	var parser dnsmessage.Parser
+
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, false
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		return nil, false
+	}
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ //nolint:exhaustruct // flags default to false
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+	})
+
+	_ = builder.StartQuestions()
+	_ = builder.Question(question)
+	_ = builder.StartAnswers()
+
+	if question.Type == dnsmessage.TypeMX {
+		//nolint:exhaustruct // the length is computed by the builder
+		record := dnsmessage.ResourceHeader{
+			Name:  question.Name,
+			Type:  dnsmessage.TypeMX,
+			Class: dnsmessage.ClassINET,
+			TTL:   60,
+		}
+
+		_ = builder.MXResource(record, dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mx.stub.test.")})
+	}
+
+	answer, err := builder.Finish()
+
+	return answer, err == nil
+}
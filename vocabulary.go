@@ -0,0 +1,34 @@
+package emailscraper
+
+// SeparatorVocabulary lists the words a language writes instead of "@" and "." to obfuscate addresses,
+// e.g. "arroba" and "punto" in Spanish. Words are matched case-insensitively as whole words, or inside
+// brackets such as "[arroba]".
+type SeparatorVocabulary struct {
+	// Language names the vocabulary, e.g. an ISO 639-1 code. It is informational only.
+	Language string
+	// At are the words written instead of "@".
+	At []string
+	// Dot are the words written instead of ".".
+	Dot []string
+}
+
+// DefaultSeparatorVocabularies returns the built-in vocabularies of common European and Latin American
+// languages. Append to the result to add more, or drop the ones that cause false matches on your sites.
+func DefaultSeparatorVocabularies() []SeparatorVocabulary {
+	return []SeparatorVocabulary{
+		{Language: "en", At: []string{"at"}, Dot: []string{"dot"}},
+		{Language: "es", At: []string{"arroba"}, Dot: []string{"punto"}},
+		{Language: "pt", At: []string{"arroba"}, Dot: []string{"ponto"}},
+		{Language: "fr", At: []string{"arobase", "arrobase", "chez"}, Dot: []string{"point"}},
+		{Language: "de", At: []string{"at", "klammeraffe"}, Dot: []string{"punkt"}},
+		{Language: "it", At: []string{"chiocciola"}, Dot: []string{"punto"}},
+		{Language: "nl", At: []string{"apenstaartje"}, Dot: []string{"punt"}},
+		{Language: "sv", At: []string{"snabel-a"}, Dot: []string{"punkt"}},
+		{Language: "da", At: []string{"snabel-a"}, Dot: []string{"punktum"}},
+		{Language: "pl", At: []string{"małpa"}, Dot: []string{"kropka"}},
+		{Language: "cs", At: []string{"zavináč"}, Dot: []string{"tečka"}},
+		{Language: "hu", At: []string{"kukac"}, Dot: []string{"pont"}},
+		{Language: "ru", At: []string{"собака"}, Dot: []string{"точка"}},
+		{Language: "uk", At: []string{"равлик"}, Dot: []string{"крапка"}},
+	}
+}